@@ -0,0 +1,98 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+)
+
+// ApprovalDecision is the outcome of an approval request raised by the
+// agent (for example before running a shell command).
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionApprove ApprovalDecision = "approve"
+	ApprovalDecisionDeny    ApprovalDecision = "deny"
+)
+
+// CommandExecutionApprovalParams describes a pending command the agent
+// wants to run.
+type CommandExecutionApprovalParams struct {
+	ThreadID string `json:"threadId"`
+	TurnID   string `json:"turnId"`
+	ItemID   string `json:"itemId"`
+	Command  string `json:"command"`
+	// Cwd is the working directory the turn is running in.
+	Cwd string `json:"cwd"`
+	// SandboxPolicy is the turn's sandbox policy, in the same opaque shape
+	// accepted by Codex.ValidateSandbox.
+	SandboxPolicy any `json:"sandboxPolicy"`
+	// Reason is the justification the model gave for wanting to run this
+	// command, if it gave one.
+	Reason string `json:"reason"`
+}
+
+// FileChangeApprovalParams describes a pending patch the agent wants to
+// apply.
+type FileChangeApprovalParams struct {
+	ThreadID string   `json:"threadId"`
+	TurnID   string   `json:"turnId"`
+	ItemID   string   `json:"itemId"`
+	Paths    []string `json:"paths"`
+	// Cwd is the working directory the turn is running in.
+	Cwd string `json:"cwd"`
+	// SandboxPolicy is the turn's sandbox policy, in the same opaque shape
+	// accepted by Codex.ValidateSandbox.
+	SandboxPolicy any `json:"sandboxPolicy"`
+	// Reason is the justification the model gave for wanting to apply this
+	// patch, if it gave one.
+	Reason string `json:"reason"`
+}
+
+// ToolUserInputApprovalParams describes a question a tool is asking the
+// user mid-turn.
+type ToolUserInputApprovalParams struct {
+	ThreadID string `json:"threadId"`
+	TurnID   string `json:"turnId"`
+	ItemID   string `json:"itemId"`
+	Question string `json:"question"`
+}
+
+// ApprovalHandler decides how the SDK responds to approval requests raised
+// by the app-server during a turn.
+type ApprovalHandler interface {
+	// ItemCommandExecutionRequestApproval is called before the agent runs a
+	// shell command.
+	ItemCommandExecutionRequestApproval(ctx context.Context, params CommandExecutionApprovalParams) (ApprovalDecision, error)
+	// ItemFileChangeRequestApproval is called before the agent applies a
+	// patch.
+	ItemFileChangeRequestApproval(ctx context.Context, params FileChangeApprovalParams) (ApprovalDecision, error)
+	// ItemToolRequestUserInput is called when a tool asks the user a
+	// question mid-turn. The default handler has no interactive surface to
+	// answer through, so it returns an error.
+	ItemToolRequestUserInput(ctx context.Context, params ToolUserInputApprovalParams) (string, error)
+}
+
+// AutoApproveHandler approves every command execution and file change
+// request. It is useful for unattended/batch usage but should not be used
+// for untrusted prompts.
+type AutoApproveHandler struct {
+	// ToolInputAnswer, when set, is returned as a canned answer whenever a
+	// tool asks the user a question mid-turn, for non-interactive batch use.
+	// If empty, ItemToolRequestUserInput reports that it can't answer.
+	ToolInputAnswer string
+}
+
+func (AutoApproveHandler) ItemCommandExecutionRequestApproval(context.Context, CommandExecutionApprovalParams) (ApprovalDecision, error) {
+	return ApprovalDecisionApprove, nil
+}
+
+func (AutoApproveHandler) ItemFileChangeRequestApproval(context.Context, FileChangeApprovalParams) (ApprovalDecision, error) {
+	return ApprovalDecisionApprove, nil
+}
+
+func (h AutoApproveHandler) ItemToolRequestUserInput(context.Context, ToolUserInputApprovalParams) (string, error) {
+	if h.ToolInputAnswer != "" {
+		return h.ToolInputAnswer, nil
+	}
+	return "", fmt.Errorf("%w: AutoApproveHandler cannot answer tool-requested user input", ErrNotImplemented)
+}
@@ -0,0 +1,84 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// handleServerRequest answers requests the app-server raises against the
+// client during a turn, routing each known method to the configured
+// ApprovalHandler. It is wired in as the rpc.Client's RequestHandler, which
+// runs it on its own goroutine so a handler waiting on a human decision
+// never blocks delivery of other threads' notifications.
+//
+// item/tool/requestUserInput is additionally mirrored onto the client's
+// notification subscribers before the handler is asked to answer it, so a
+// TurnStream consumer can observe the pending question (for example to
+// render an input box) without itself being an ApprovalHandler.
+func (c *Codex) handleServerRequest(method string, params any) (any, error) {
+	ctx := contextForServerRequest(params)
+	switch method {
+	case "item/commandExecution/requestApproval":
+		var p CommandExecutionApprovalParams
+		if err := decodeServerRequestParams(params, &p); err != nil {
+			return nil, err
+		}
+		decision, err := c.approvalHandler.ItemCommandExecutionRequestApproval(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"decision": decision}, nil
+	case "item/fileChange/requestApproval":
+		var p FileChangeApprovalParams
+		if err := decodeServerRequestParams(params, &p); err != nil {
+			return nil, err
+		}
+		decision, err := c.approvalHandler.ItemFileChangeRequestApproval(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"decision": decision}, nil
+	case "item/tool/requestUserInput":
+		var p ToolUserInputApprovalParams
+		if err := decodeServerRequestParams(params, &p); err != nil {
+			return nil, err
+		}
+		c.currentClient().PublishNotification(rpc.Notification{Method: method, Params: params})
+		answer, err := c.approvalHandler.ItemToolRequestUserInput(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"answer": answer}, nil
+	default:
+		return nil, rpc.ErrMethodNotFound
+	}
+}
+
+// contextForServerRequest attaches the thread and turn ids embedded in a
+// server request's params to a fresh context.Context, so an ApprovalHandler
+// can recover them via ThreadIDFromContext and TurnIDFromContext without
+// unpacking its own params argument. Every approval param struct carries
+// these fields, so this is best-effort: if decoding fails, the handler just
+// runs with an empty-valued context rather than failing the request.
+func contextForServerRequest(params any) context.Context {
+	var ids struct {
+		ThreadID string `json:"threadId"`
+		TurnID   string `json:"turnId"`
+	}
+	_ = decodeServerRequestParams(params, &ids)
+	return withThreadAndTurnID(context.Background(), ids.ThreadID, ids.TurnID)
+}
+
+func decodeServerRequestParams(params any, dst any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("codex: marshal server request params: %w", err)
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("codex: decode server request params: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package codex
+
+import (
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestHandleServerRequestPassesTurnContextToCommandApproval(t *testing.T) {
+	captured := &recordingApprovalHandler{decision: ApprovalDecisionApprove}
+	c := &Codex{approvalHandler: captured}
+
+	_, err := c.handleServerRequest("item/commandExecution/requestApproval", map[string]any{
+		"threadId":      "thread-1",
+		"turnId":        "turn-1",
+		"itemId":        "item-1",
+		"command":       "rm -rf /tmp/scratch",
+		"cwd":           "/work",
+		"sandboxPolicy": map[string]any{"mode": "workspace-write"},
+		"reason":        "cleaning up scratch files",
+	})
+	if err != nil {
+		t.Fatalf("handleServerRequest: %v", err)
+	}
+	if captured.lastCommandParams.Cwd != "/work" {
+		t.Fatalf("Cwd = %q, want /work", captured.lastCommandParams.Cwd)
+	}
+	if captured.lastCommandParams.Reason != "cleaning up scratch files" {
+		t.Fatalf("Reason = %q, want cleaning up scratch files", captured.lastCommandParams.Reason)
+	}
+	policy, ok := captured.lastCommandParams.SandboxPolicy.(map[string]any)
+	if !ok || policy["mode"] != "workspace-write" {
+		t.Fatalf("SandboxPolicy = %v, want mode workspace-write", captured.lastCommandParams.SandboxPolicy)
+	}
+	if got := ThreadIDFromContext(captured.lastCommandCtx); got != "thread-1" {
+		t.Fatalf("ThreadIDFromContext = %q, want thread-1", got)
+	}
+	if got := TurnIDFromContext(captured.lastCommandCtx); got != "turn-1" {
+		t.Fatalf("TurnIDFromContext = %q, want turn-1", got)
+	}
+}
+
+func TestHandleServerRequestPublishesToolInputRequestAsNotification(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client, approvalHandler: AutoApproveHandler{ToolInputAnswer: "edit main.go"}}
+
+	sub := client.SubscribeNotifications(1)
+	defer sub.Close()
+
+	_, err := c.handleServerRequest("item/tool/requestUserInput", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"itemId":   "item-1",
+		"question": "which file should I edit?",
+	})
+	if err != nil {
+		t.Fatalf("handleServerRequest: %v", err)
+	}
+
+	note, ok := sub.Next()
+	if !ok {
+		t.Fatal("SubscribeNotifications: want the mirrored item/tool/requestUserInput notification")
+	}
+	if note.Method != "item/tool/requestUserInput" {
+		t.Fatalf("Method = %q, want item/tool/requestUserInput", note.Method)
+	}
+	params, ok := note.Params.(map[string]any)
+	if !ok || params["question"] != "which file should I edit?" {
+		t.Fatalf("Params = %v, want question which file should I edit?", note.Params)
+	}
+}
@@ -0,0 +1,459 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// Options configures a Codex client.
+type Options struct {
+	// CodexPathOverride overrides the path to the codex binary. Defaults to
+	// resolving "codex" on PATH.
+	CodexPathOverride string
+	// Env, when non-nil, replaces the app-server subprocess environment.
+	Env []string
+	// ConfigOverrides are raw "key=value" strings passed to the app-server
+	// as --config flags.
+	ConfigOverrides []string
+	// ApprovalHandler decides how to respond to approval requests raised by
+	// the app-server. Defaults to AutoApproveHandler.
+	ApprovalHandler ApprovalHandler
+	// Stderr, when set, receives the app-server subprocess's standard
+	// error stream for the lifetime of the Codex.
+	Stderr io.Writer
+	// AllowedInputRoots, when non-empty, restricts LocalImageInput (and
+	// other local-path inputs) to paths inside one of these directories.
+	// Inputs referencing a path outside all of them are rejected with
+	// ErrPathNotAllowed before being sent to the app-server. This gives
+	// multi-tenant servers that accept user-supplied paths defense in
+	// depth against path traversal.
+	AllowedInputRoots []string
+	// FirstByteTimeout, when greater than zero, bounds how long New waits
+	// for the app-server subprocess to produce any output at all. If it
+	// elapses first, New kills the subprocess and returns ErrNoResponse
+	// instead of leaving the caller waiting on a process that never
+	// started correctly. Zero disables this fast path, so New waits for
+	// initialize to answer or fail.
+	FirstByteTimeout time.Duration
+	// MaxInflight, when greater than zero, bounds how many turn/start or
+	// turn/continue requests can be sent and awaiting their ack at once.
+	// Callers that exceed it queue locally, ordered by TurnOptions.Priority
+	// so interactive turns dispatch ahead of background ones queued behind
+	// the same limit. Zero disables this: every turn dispatches as soon as
+	// its caller invokes Run/RunStreamed, matching prior behavior.
+	MaxInflight int
+	// Reconnect, when its MaxAttempts is non-zero, makes Codex respawn the
+	// app-server and resume its open threads automatically if the
+	// transport drops (subprocess crash, dropped socket), instead of
+	// leaving the Codex permanently unusable. Turns already in flight when
+	// the transport drops are not retried automatically; their stream
+	// surfaces an error so the caller can retry them itself.
+	Reconnect ReconnectPolicy
+	// Tracer, when set, is passed through to rpc.ClientOptions.Tracer for
+	// the underlying RPC client, and additionally used to start a span
+	// around each turn (see TurnStream), covering Thread.Run/RunStreamed
+	// from turn/start or turn/continue through turn/completed. Defaults to
+	// rpc.NoopTracer(), so tracing is zero-cost until a caller supplies
+	// one.
+	Tracer rpc.Tracer
+	// InitializeRetries bounds how many additional attempts New makes at the
+	// initialize handshake if it fails, with a short backoff between each.
+	// This covers a just-spawned app-server subprocess whose stdio loop
+	// isn't reading yet, which can otherwise make the very first request
+	// race and fail. Zero (the default) makes a single attempt, matching
+	// prior behavior. It only applies to initialize itself; a binary that
+	// fails to spawn at all still fails fast.
+	InitializeRetries int
+	// Logger receives structured log lines from this Codex and the Threads
+	// it starts or resumes. Every Thread's logger is a child of this one
+	// with a "threadId" field baked in (see resolveLogger), so log lines
+	// from many concurrently running threads can be told apart. Defaults
+	// to slog.Default().
+	Logger *slog.Logger
+}
+
+// resolveLogger returns logger, or slog.Default() if logger is nil, so
+// every call site that derives a child logger has one predictable place to
+// fall back to instead of nil-checking at each use.
+func resolveLogger(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// initializeRetryBackoff is the delay before each retried initialize
+// attempt, scaled by attempt number so a slow-starting subprocess gets
+// progressively more time to come up.
+const initializeRetryBackoff = 50 * time.Millisecond
+
+// Codex is the main entry point for interacting with the Codex agent. A
+// single Codex owns one app-server subprocess and can host many concurrent
+// Threads.
+type Codex struct {
+	client             *rpc.Client
+	transport          rpc.Transport
+	approvalHandler    ApprovalHandler
+	allowedInputRoots  []string
+	serverCapabilities map[string]bool
+	serverInfo         ServerInfo
+	transcript         *transcriptBroadcaster
+	turnQueue          *turnQueue
+
+	// spawnPath and spawnOptions are retained only so respawn can start an
+	// identical app-server subprocess after the transport drops; they're
+	// unused unless Options.Reconnect.MaxAttempts is non-zero.
+	spawnPath     string
+	spawnOptions  rpc.SpawnOptions
+	reconnect     ReconnectPolicy
+	openThreadIDs map[string]struct{}
+	tracer        rpc.Tracer
+	logger        *slog.Logger
+
+	mu       sync.Mutex
+	draining bool
+	closed   bool
+	turns    sync.WaitGroup
+}
+
+// New spawns the app-server and performs the initialize handshake.
+func New(opts Options) (*Codex, error) {
+	path := opts.CodexPathOverride
+	if path == "" {
+		path = "codex"
+	}
+
+	var stderr stderrWatcher
+	transport, err := rpc.SpawnStdio(path, rpc.SpawnOptions{
+		Args:   configArgs(opts.ConfigOverrides),
+		Env:    opts.Env,
+		Stderr: stderr.writer(opts.Stderr),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("codex: spawn app-server: %w", err)
+	}
+
+	handler := opts.ApprovalHandler
+	if handler == nil {
+		handler = AutoApproveHandler{}
+	}
+
+	transcript := &transcriptBroadcaster{}
+	transport = rpc.NewTapTransport(transport, transcript.onRead, transcript.onWrite)
+
+	var firstByte *firstByteWatcher
+	if opts.FirstByteTimeout > 0 {
+		firstByte = newFirstByteWatcher()
+		transport = rpc.NewTapTransport(transport, firstByte.onRead, nil)
+	}
+
+	var queue *turnQueue
+	if opts.MaxInflight > 0 {
+		queue = newTurnQueue(opts.MaxInflight)
+	}
+
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = rpc.NoopTracer()
+	}
+
+	c := &Codex{
+		transport:          transport,
+		approvalHandler:    handler,
+		allowedInputRoots:  opts.AllowedInputRoots,
+		serverCapabilities: map[string]bool{},
+		transcript:         transcript,
+		turnQueue:          queue,
+		spawnPath:          path,
+		spawnOptions:       rpc.SpawnOptions{Args: configArgs(opts.ConfigOverrides), Env: opts.Env, Stderr: opts.Stderr},
+		reconnect:          opts.Reconnect,
+		openThreadIDs:      map[string]struct{}{},
+		tracer:             tracer,
+		logger:             resolveLogger(opts.Logger),
+	}
+	c.client = rpc.NewClient(transport, rpc.ClientOptions{RequestHandler: c.handleServerRequest, Tracer: tracer})
+
+	var initResp struct {
+		UserAgent      string   `json:"userAgent"`
+		CodexHome      string   `json:"codexHome"`
+		PlatformFamily string   `json:"platformFamily"`
+		PlatformOS     string   `json:"platformOs"`
+		Capabilities   []string `json:"capabilities"`
+	}
+	initDone := make(chan error, 1)
+	go func() {
+		initDone <- c.initializeWithRetry(opts.InitializeRetries, &initResp)
+	}()
+
+	var firstByteTimedOut <-chan struct{}
+	if firstByte != nil {
+		firstByteTimedOut = firstByte.timedOut(opts.FirstByteTimeout)
+	}
+
+	select {
+	case err := <-initDone:
+		if err != nil {
+			_ = c.client.Close()
+			return nil, fmt.Errorf("codex: initialize: %w", err)
+		}
+	case <-stderr.wroteAny():
+		_ = c.client.Close()
+		return nil, fmt.Errorf("codex: app-server wrote to stderr before completing initialize: %s", stderr.String())
+	case <-firstByteTimedOut:
+		_ = c.client.Close()
+		return nil, ErrNoResponse
+	}
+	for _, capability := range initResp.Capabilities {
+		c.serverCapabilities[capability] = true
+	}
+	c.serverInfo = ServerInfo{
+		UserAgent:      initResp.UserAgent,
+		CodexHome:      initResp.CodexHome,
+		PlatformFamily: initResp.PlatformFamily,
+		PlatformOS:     initResp.PlatformOS,
+		Capabilities:   initResp.Capabilities,
+	}
+	if opts.Reconnect.MaxAttempts > 0 {
+		go c.watchForDisconnect()
+	}
+	return c, nil
+}
+
+// initializeWithRetry runs the initialize call, retrying up to retries more
+// times with a short backoff if it fails, before giving up and returning
+// the last attempt's error.
+func (c *Codex) initializeWithRetry(retries int, result any) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(initializeRetryBackoff * time.Duration(attempt))
+		}
+		if err = c.client.Call("initialize", map[string]any{}, result); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("after %d attempt(s): %w", retries+1, err)
+}
+
+// currentClient returns the client currently in use, guarding against the
+// swap respawn performs after a reconnect. Call sites that run after
+// initialize (as opposed to initialize itself, which only ever runs
+// against the client New just created) should read c.client through this
+// instead of the field directly.
+func (c *Codex) currentClient() *rpc.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client
+}
+
+// supportsCapability reports whether the app-server advertised capability
+// in its initialize response. App-servers that don't report capabilities
+// at all (the common case today) never satisfy this, so features gated on
+// it silently fall back rather than sending a param the server ignores or
+// rejects.
+func (c *Codex) supportsCapability(capability string) bool {
+	return c.serverCapabilities[capability]
+}
+
+// ServerInfo describes the app-server process New connected to, gathered
+// from the initialize handshake's response.
+type ServerInfo struct {
+	// UserAgent is the app-server's self-reported user agent string.
+	UserAgent string
+	// CodexHome is the absolute path to the app-server's $CODEX_HOME.
+	CodexHome string
+	// PlatformFamily is the platform family the app-server is running on,
+	// for example "unix" or "windows".
+	PlatformFamily string
+	// PlatformOS is the operating system the app-server is running on, for
+	// example "macos", "linux", or "windows".
+	PlatformOS string
+	// Capabilities lists the capability strings the app-server advertised.
+	// Most app-servers today report none, the same forward-compat gap
+	// supportsCapability already accounts for; an empty slice doesn't mean
+	// the server lacks every optional feature, only that it didn't say.
+	Capabilities []string
+}
+
+// ServerInfo returns information about the connected app-server gathered
+// during the initialize handshake, so callers can feature-gate on its
+// platform or advertised capabilities (for example before attempting
+// compaction or structured output) instead of guessing. After an automatic
+// reconnect it reflects the respawned app-server's handshake.
+func (c *Codex) ServerInfo() ServerInfo {
+	return c.serverInfo
+}
+
+func configArgs(overrides []string) []string {
+	var args []string
+	for _, override := range overrides {
+		args = append(args, "--config", override)
+	}
+	return args
+}
+
+// BeginDrain stops new turns from starting: subsequent calls to
+// StartThread, ResumeThread, and Thread.Run/RunStreamed return
+// ErrDraining. Turns already in flight are unaffected and run to
+// completion; call AwaitDrained to wait for them, enabling a zero-dropped-
+// turn rolling restart.
+func (c *Codex) BeginDrain() {
+	c.mu.Lock()
+	c.draining = true
+	c.mu.Unlock()
+}
+
+// AwaitDrained blocks until every turn that was in flight when BeginDrain
+// was called has completed, or until ctx is done.
+func (c *Codex) AwaitDrained(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.turns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Codex) isDraining() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.draining
+}
+
+// StartThread starts a new conversation with the agent, discarding any
+// notifications the app-server emits while starting it (such as session
+// configuration or MCP server connection status). Use StartThreadStreamed
+// to observe them.
+func (c *Codex) StartThread(ctx context.Context, opts ThreadStartOptions) (*Thread, error) {
+	stream, err := c.StartThreadStreamed(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream.iterator.Close()
+	return stream.Thread, nil
+}
+
+// StartThreadStreamed is StartThread, additionally returning a
+// ThreadStartStream scoped to the notifications emitted while the thread
+// was starting, so a caller can surface setup progress (for example,
+// "connecting to MCP servers…" in a dashboard) instead of waiting on
+// thread/start in silence.
+func (c *Codex) StartThreadStreamed(ctx context.Context, opts ThreadStartOptions) (*ThreadStartStream, error) {
+	if c.isDraining() {
+		return nil, ErrDraining
+	}
+	client := c.currentClient()
+	iterator := client.SubscribeNotifications(64)
+
+	var resp threadWireResponse
+	if err := client.Call("thread/start", toParams(opts), &resp); err != nil {
+		iterator.Close()
+		return nil, fmt.Errorf("codex: thread/start: %w", err)
+	}
+	thread, err := resp.toThread(c)
+	if err != nil {
+		iterator.Close()
+		return nil, fmt.Errorf("codex: thread/start: %w", err)
+	}
+	c.trackOpenThread(thread.id)
+
+	return &ThreadStartStream{
+		Thread:   thread,
+		iterator: iterator,
+		threadID: thread.id,
+	}, nil
+}
+
+// ResumeThread resumes a previously started thread by id.
+func (c *Codex) ResumeThread(ctx context.Context, id string, opts ThreadResumeOptions) (*Thread, error) {
+	if c.isDraining() {
+		return nil, ErrDraining
+	}
+	params := toResumeParams(id, opts)
+	var resp threadWireResponse
+	if err := c.currentClient().Call("thread/resume", params, &resp); err != nil {
+		return nil, fmt.Errorf("codex: thread/resume: %w", err)
+	}
+	thread, err := resp.toThread(c)
+	if err != nil {
+		return nil, fmt.Errorf("codex: thread/resume: %w", err)
+	}
+	c.trackOpenThread(thread.id)
+	return thread, nil
+}
+
+// Ping sends a lightweight no-op RPC and returns how long the app-server
+// took to answer, useful for connection pooling, readiness probes in
+// containers, and detecting a hung subprocess before committing a long
+// turn. It respects ctx: since Client.Call has no context parameter of its
+// own, Ping races it in a goroutine against ctx.Done and returns a
+// *PingTimeoutError if ctx expires first, rather than blocking forever.
+func (c *Codex) Ping(ctx context.Context) (time.Duration, error) {
+	client := c.currentClient()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Call("ping", nil, nil)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return 0, fmt.Errorf("codex: ping: %w", err)
+		}
+		return time.Since(start), nil
+	case <-ctx.Done():
+		return 0, &PingTimeoutError{Elapsed: time.Since(start)}
+	}
+}
+
+// Close shuts down the app-server subprocess.
+func (c *Codex) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	client := c.client
+	c.mu.Unlock()
+	return client.Close()
+}
+
+// threadWireResponse is the shape shared by thread/start and thread/resume
+// responses: a nested "thread" object plus, for a resume, any pre-existing
+// history items.
+type threadWireResponse struct {
+	Thread struct {
+		ID               string `json:"id"`
+		Model            string `json:"model"`
+		WorkingDirectory string `json:"cwd"`
+		Path             string `json:"path"`
+	} `json:"thread"`
+	Items []any `json:"items"`
+}
+
+// toThread builds a Thread out of a decoded response, carrying along
+// whatever the server confirmed (model, cwd, pre-existing items) instead of
+// just the id.
+func (r threadWireResponse) toThread(c *Codex) (*Thread, error) {
+	if r.Thread.ID == "" {
+		return nil, ErrThreadIDNotFound
+	}
+	return &Thread{
+		codex:            c,
+		id:               r.Thread.ID,
+		model:            r.Thread.Model,
+		workingDirectory: r.Thread.WorkingDirectory,
+		items:            r.Items,
+		rolloutPath:      r.Thread.Path,
+		logger:           resolveLogger(c.logger).With("threadId", r.Thread.ID),
+	}, nil
+}
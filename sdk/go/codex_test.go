@@ -0,0 +1,288 @@
+package codex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestThreadWireResponseToThread(t *testing.T) {
+	var resp threadWireResponse
+	resp.Thread.ID = "thread-1"
+	resp.Thread.Model = "gpt-5-codex"
+	resp.Thread.WorkingDirectory = "/work"
+	resp.Thread.Path = "/home/user/.codex/sessions/thread-1.jsonl"
+	resp.Items = []any{"previous message"}
+
+	thread, err := resp.toThread(&Codex{})
+	if err != nil {
+		t.Fatalf("toThread: %v", err)
+	}
+	if thread.ID() != "thread-1" {
+		t.Fatalf("ID() = %q, want thread-1", thread.ID())
+	}
+	if thread.Model() != "gpt-5-codex" {
+		t.Fatalf("Model() = %q, want gpt-5-codex", thread.Model())
+	}
+	if thread.WorkingDirectory() != "/work" {
+		t.Fatalf("WorkingDirectory() = %q, want /work", thread.WorkingDirectory())
+	}
+	if len(thread.Items()) != 1 || thread.Items()[0] != "previous message" {
+		t.Fatalf("Items() = %v, want [previous message]", thread.Items())
+	}
+	if thread.RolloutPath() != "/home/user/.codex/sessions/thread-1.jsonl" {
+		t.Fatalf("RolloutPath() = %q, want the thread's rollout path", thread.RolloutPath())
+	}
+}
+
+func TestThreadWireResponseToThreadDerivesLoggerWithThreadID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	var resp threadWireResponse
+	resp.Thread.ID = "thread-1"
+	thread, err := resp.toThread(&Codex{logger: logger})
+	if err != nil {
+		t.Fatalf("toThread: %v", err)
+	}
+
+	thread.Logger().Info("hello")
+	if !strings.Contains(buf.String(), "threadId=thread-1") {
+		t.Fatalf("log output = %q, want it to contain threadId=thread-1", buf.String())
+	}
+}
+
+func TestResolveLoggerFallsBackToDefault(t *testing.T) {
+	if resolveLogger(nil) == nil {
+		t.Fatal("resolveLogger(nil) = nil, want slog.Default()")
+	}
+	custom := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if resolveLogger(custom) != custom {
+		t.Fatal("resolveLogger(custom) did not return custom logger unchanged")
+	}
+}
+
+func TestThreadWireResponseToThreadRequiresID(t *testing.T) {
+	var resp threadWireResponse
+	_, err := resp.toThread(&Codex{})
+	if !errors.Is(err, ErrThreadIDNotFound) {
+		t.Fatalf("toThread error = %v, want ErrThreadIDNotFound", err)
+	}
+}
+
+func TestBeginDrainRejectsNewThreads(t *testing.T) {
+	c := &Codex{}
+	c.BeginDrain()
+
+	if _, err := c.StartThread(context.Background(), ThreadStartOptions{}); !errors.Is(err, ErrDraining) {
+		t.Fatalf("StartThread error = %v, want ErrDraining", err)
+	}
+	if _, err := c.ResumeThread(context.Background(), "thread-1", ThreadResumeOptions{}); !errors.Is(err, ErrDraining) {
+		t.Fatalf("ResumeThread error = %v, want ErrDraining", err)
+	}
+}
+
+func TestStartThreadStreamedReturnsSetupNotifications(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.push(notificationLine(t, "mcp/connectionStatus", map[string]any{"server": "fs", "status": "connected"}))
+	transport.push(`{"id":1,"result":{"thread":{"id":"thread-1","model":"gpt-5-codex","cwd":"/work"}}}`)
+
+	stream, err := c.StartThreadStreamed(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThreadStreamed: %v", err)
+	}
+	defer stream.Close()
+
+	if stream.Thread.ID() != "thread-1" {
+		t.Fatalf("Thread.ID() = %q, want thread-1", stream.Thread.ID())
+	}
+
+	note, ok := stream.Next()
+	if !ok {
+		t.Fatal("Next(): want a setup notification")
+	}
+	if note.Method != "mcp/connectionStatus" {
+		t.Fatalf("note.Method = %q, want mcp/connectionStatus", note.Method)
+	}
+}
+
+func TestStartThreadDiscardsSetupNotificationsAndReturnsThread(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.push(notificationLine(t, "mcp/connectionStatus", map[string]any{"server": "fs", "status": "connected"}))
+	transport.push(`{"id":1,"result":{"thread":{"id":"thread-1","model":"gpt-5-codex","cwd":"/work"}}}`)
+
+	thread, err := c.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	if thread.ID() != "thread-1" {
+		t.Fatalf("ID() = %q, want thread-1", thread.ID())
+	}
+}
+
+func TestStartThreadReturnsRolloutPathFromResponse(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.push(`{"id":1,"result":{"thread":{"id":"thread-1","model":"gpt-5-codex","cwd":"/work","path":"/home/user/.codex/sessions/thread-1.jsonl"}}}`)
+
+	thread, err := c.StartThread(context.Background(), ThreadStartOptions{})
+	if err != nil {
+		t.Fatalf("StartThread: %v", err)
+	}
+	if thread.RolloutPath() != "/home/user/.codex/sessions/thread-1.jsonl" {
+		t.Fatalf("RolloutPath() = %q, want the thread/start response's rollout path", thread.RolloutPath())
+	}
+}
+
+func TestServerInfoReturnsHandshakeInfo(t *testing.T) {
+	c := &Codex{serverInfo: ServerInfo{
+		UserAgent:      "codex_cli_rs/0.1.0 (linux; x86_64)",
+		CodexHome:      "/home/user/.codex",
+		PlatformFamily: "unix",
+		PlatformOS:     "linux",
+		Capabilities:   []string{"compaction"},
+	}}
+
+	info := c.ServerInfo()
+	if info.UserAgent != "codex_cli_rs/0.1.0 (linux; x86_64)" {
+		t.Fatalf("UserAgent = %q, want codex_cli_rs/0.1.0 (linux; x86_64)", info.UserAgent)
+	}
+	if info.PlatformOS != "linux" {
+		t.Fatalf("PlatformOS = %q, want linux", info.PlatformOS)
+	}
+	if len(info.Capabilities) != 1 || info.Capabilities[0] != "compaction" {
+		t.Fatalf("Capabilities = %v, want [compaction]", info.Capabilities)
+	}
+}
+
+func TestInitializeWithRetryRetriesTransientFailures(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{CallTimeout: time.Second})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.onWrite = func(n int, line string) {
+		switch n {
+		case 1:
+			transport.push(`{"id":1,"error":{"code":-32000,"message":"not ready"}}`)
+		case 2:
+			transport.push(`{"id":2,"error":{"code":-32000,"message":"not ready"}}`)
+		case 3:
+			transport.push(`{"id":3,"result":{"userAgent":"codex_cli_rs/0.1.0"}}`)
+		}
+	}
+
+	var resp struct {
+		UserAgent string `json:"userAgent"`
+	}
+	if err := c.initializeWithRetry(2, &resp); err != nil {
+		t.Fatalf("initializeWithRetry: %v", err)
+	}
+	if resp.UserAgent != "codex_cli_rs/0.1.0" {
+		t.Fatalf("UserAgent = %q, want codex_cli_rs/0.1.0", resp.UserAgent)
+	}
+	if len(transport.written) != 3 {
+		t.Fatalf("written = %d requests, want 3 attempts", len(transport.written))
+	}
+}
+
+func TestInitializeWithRetryReturnsUnderlyingErrorAfterExhausted(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{CallTimeout: time.Second})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.onWrite = func(n int, line string) {
+		transport.push(`{"id":` + strconv.Itoa(n) + `,"error":{"code":-32000,"message":"not ready"}}`)
+	}
+
+	var resp struct{}
+	err := c.initializeWithRetry(1, &resp)
+	if err == nil {
+		t.Fatal("initializeWithRetry: want an error after exhausting retries")
+	}
+	var rpcErr *rpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("err = %v, want it to wrap *rpc.RPCError", err)
+	}
+	if len(transport.written) != 2 {
+		t.Fatalf("written = %d requests, want 2 attempts", len(transport.written))
+	}
+}
+
+func TestPingReturnsElapsedDurationOnSuccess(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.push(`{"id":1,"result":{}}`)
+
+	elapsed, err := c.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if elapsed < 0 {
+		t.Fatalf("elapsed = %v, want >= 0", elapsed)
+	}
+}
+
+func TestPingReturnsTimeoutErrorWhenContextExpiresFirst(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Ping(ctx)
+	var timeoutErr *PingTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Ping error = %v, want *PingTimeoutError", err)
+	}
+	if !errors.Is(err, ErrPingTimeout) {
+		t.Fatal("Ping error should unwrap to ErrPingTimeout")
+	}
+}
+
+func TestAwaitDrainedWaitsForInFlightTurns(t *testing.T) {
+	c := &Codex{}
+	c.turns.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.AwaitDrained(ctx); err == nil {
+		t.Fatalf("expected AwaitDrained to time out while a turn is in flight")
+	}
+
+	c.turns.Done()
+	if err := c.AwaitDrained(context.Background()); err != nil {
+		t.Fatalf("AwaitDrained: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+)
+
+// CompositeApprovalHandler dispatches each approval method to its own func,
+// falling back to Default for whichever one isn't set, so a caller that
+// only cares about one request kind doesn't have to embed AutoApproveHandler
+// (or implement the other two methods) just to satisfy ApprovalHandler.
+type CompositeApprovalHandler struct {
+	// CommandExecution handles ItemCommandExecutionRequestApproval. If nil,
+	// the call is forwarded to Default.
+	CommandExecution func(ctx context.Context, params CommandExecutionApprovalParams) (ApprovalDecision, error)
+	// FileChange handles ItemFileChangeRequestApproval. If nil, the call is
+	// forwarded to Default.
+	FileChange func(ctx context.Context, params FileChangeApprovalParams) (ApprovalDecision, error)
+	// ToolUserInput handles ItemToolRequestUserInput. If nil, the call is
+	// forwarded to Default.
+	ToolUserInput func(ctx context.Context, params ToolUserInputApprovalParams) (string, error)
+	// Default handles any method whose func above is nil. If Default is
+	// also nil, that method reports it can't decide.
+	Default ApprovalHandler
+}
+
+func (h CompositeApprovalHandler) ItemCommandExecutionRequestApproval(ctx context.Context, params CommandExecutionApprovalParams) (ApprovalDecision, error) {
+	if h.CommandExecution != nil {
+		return h.CommandExecution(ctx, params)
+	}
+	if h.Default != nil {
+		return h.Default.ItemCommandExecutionRequestApproval(ctx, params)
+	}
+	return "", fmt.Errorf("%w: CompositeApprovalHandler has no CommandExecution func or Default", ErrNotImplemented)
+}
+
+func (h CompositeApprovalHandler) ItemFileChangeRequestApproval(ctx context.Context, params FileChangeApprovalParams) (ApprovalDecision, error) {
+	if h.FileChange != nil {
+		return h.FileChange(ctx, params)
+	}
+	if h.Default != nil {
+		return h.Default.ItemFileChangeRequestApproval(ctx, params)
+	}
+	return "", fmt.Errorf("%w: CompositeApprovalHandler has no FileChange func or Default", ErrNotImplemented)
+}
+
+func (h CompositeApprovalHandler) ItemToolRequestUserInput(ctx context.Context, params ToolUserInputApprovalParams) (string, error) {
+	if h.ToolUserInput != nil {
+		return h.ToolUserInput(ctx, params)
+	}
+	if h.Default != nil {
+		return h.Default.ItemToolRequestUserInput(ctx, params)
+	}
+	return "", fmt.Errorf("%w: CompositeApprovalHandler has no ToolUserInput func or Default", ErrNotImplemented)
+}
@@ -0,0 +1,72 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCompositeApprovalHandlerRoutesCommandExecutionToItsFunc(t *testing.T) {
+	called := false
+	h := CompositeApprovalHandler{
+		CommandExecution: func(ctx context.Context, params CommandExecutionApprovalParams) (ApprovalDecision, error) {
+			called = true
+			return ApprovalDecisionApprove, nil
+		},
+	}
+
+	decision, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "go test"})
+	if err != nil {
+		t.Fatalf("ItemCommandExecutionRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionApprove || !called {
+		t.Fatalf("decision = %q, called = %v, want approve via CommandExecution", decision, called)
+	}
+}
+
+func TestCompositeApprovalHandlerFallsBackToDefaultWhenFuncUnset(t *testing.T) {
+	fallback := &recordingApprovalHandler{decision: ApprovalDecisionDeny}
+	h := CompositeApprovalHandler{Default: fallback}
+
+	decision, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "rm -rf /"})
+	if err != nil {
+		t.Fatalf("ItemCommandExecutionRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionDeny || !fallback.commandCalled {
+		t.Fatalf("decision = %q, commandCalled = %v, want deny via Default", decision, fallback.commandCalled)
+	}
+}
+
+func TestCompositeApprovalHandlerFileChangeAndToolUserInput(t *testing.T) {
+	h := CompositeApprovalHandler{
+		FileChange: func(ctx context.Context, params FileChangeApprovalParams) (ApprovalDecision, error) {
+			return ApprovalDecisionApprove, nil
+		},
+		ToolUserInput: func(ctx context.Context, params ToolUserInputApprovalParams) (string, error) {
+			return "yes", nil
+		},
+	}
+
+	decision, err := h.ItemFileChangeRequestApproval(context.Background(), FileChangeApprovalParams{Paths: []string{"main.go"}})
+	if err != nil || decision != ApprovalDecisionApprove {
+		t.Fatalf("ItemFileChangeRequestApproval = %q, %v, want approve", decision, err)
+	}
+
+	answer, err := h.ItemToolRequestUserInput(context.Background(), ToolUserInputApprovalParams{Question: "proceed?"})
+	if err != nil || answer != "yes" {
+		t.Fatalf("ItemToolRequestUserInput = %q, %v, want yes", answer, err)
+	}
+}
+
+func TestCompositeApprovalHandlerWithoutFuncOrDefaultErrors(t *testing.T) {
+	h := CompositeApprovalHandler{}
+	if _, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{}); !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("err = %v, want ErrNotImplemented", err)
+	}
+	if _, err := h.ItemFileChangeRequestApproval(context.Background(), FileChangeApprovalParams{}); !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("err = %v, want ErrNotImplemented", err)
+	}
+	if _, err := h.ItemToolRequestUserInput(context.Background(), ToolUserInputApprovalParams{}); !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("err = %v, want ErrNotImplemented", err)
+	}
+}
@@ -0,0 +1,107 @@
+package codex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApprovalPolicy selects when the agent pauses to ask for approval before
+// running a command or applying a patch.
+type ApprovalPolicy string
+
+const (
+	ApprovalPolicyUntrusted ApprovalPolicy = "untrusted"
+	ApprovalPolicyOnFailure ApprovalPolicy = "on-failure"
+	ApprovalPolicyOnRequest ApprovalPolicy = "on-request"
+	ApprovalPolicyNever     ApprovalPolicy = "never"
+)
+
+// SandboxMode selects the execution sandbox applied to commands the agent
+// runs.
+type SandboxMode string
+
+const (
+	SandboxModeReadOnly         SandboxMode = "read-only"
+	SandboxModeWorkspaceWrite   SandboxMode = "workspace-write"
+	SandboxModeDangerFullAccess SandboxMode = "danger-full-access"
+)
+
+// ConfigBuilder builds Options.ConfigOverrides using typed setters instead
+// of hand-written "key=value" strings, which are easy to typo in ways that
+// only surface as a cryptic app-server startup failure. Zero value is
+// ready to use; setters chain and Build reports the first invalid value.
+type ConfigBuilder struct {
+	overrides []string
+	err       error
+}
+
+// NewConfigBuilder returns an empty ConfigBuilder.
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{}
+}
+
+// SetModel overrides the default model.
+func (b *ConfigBuilder) SetModel(model string) *ConfigBuilder {
+	if model == "" {
+		b.fail(fmt.Errorf("codex: SetModel: model must not be empty"))
+		return b
+	}
+	b.overrides = append(b.overrides, "model="+model)
+	return b
+}
+
+// SetApprovalPolicy overrides when the agent asks for approval.
+func (b *ConfigBuilder) SetApprovalPolicy(policy ApprovalPolicy) *ConfigBuilder {
+	switch policy {
+	case ApprovalPolicyUntrusted, ApprovalPolicyOnFailure, ApprovalPolicyOnRequest, ApprovalPolicyNever:
+		b.overrides = append(b.overrides, "approval_policy="+string(policy))
+	default:
+		b.fail(fmt.Errorf("codex: SetApprovalPolicy: unknown approval policy %q", policy))
+	}
+	return b
+}
+
+// SetSandbox overrides the execution sandbox.
+func (b *ConfigBuilder) SetSandbox(mode SandboxMode) *ConfigBuilder {
+	switch mode {
+	case SandboxModeReadOnly, SandboxModeWorkspaceWrite, SandboxModeDangerFullAccess:
+		b.overrides = append(b.overrides, "sandbox_mode="+string(mode))
+	default:
+		b.fail(fmt.Errorf("codex: SetSandbox: unknown sandbox mode %q", mode))
+	}
+	return b
+}
+
+// SetMCPServer registers (or overrides) the launch command for the MCP
+// server named name.
+func (b *ConfigBuilder) SetMCPServer(name, command string, args ...string) *ConfigBuilder {
+	if name == "" || command == "" {
+		b.fail(fmt.Errorf("codex: SetMCPServer: name and command must not be empty"))
+		return b
+	}
+	b.overrides = append(b.overrides, fmt.Sprintf("mcp_servers.%s.command=%s", name, command))
+	if len(args) > 0 {
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = fmt.Sprintf("%q", arg)
+		}
+		b.overrides = append(b.overrides, fmt.Sprintf("mcp_servers.%s.args=[%s]", name, strings.Join(quoted, ", ")))
+	}
+	return b
+}
+
+func (b *ConfigBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the accumulated overrides, ready for
+// Options.ConfigOverrides, or the first validation error encountered by a
+// setter.
+func (b *ConfigBuilder) Build() ([]string, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return append([]string(nil), b.overrides...), nil
+}
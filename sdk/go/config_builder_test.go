@@ -0,0 +1,53 @@
+package codex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigBuilderBuildsTypedOverrides(t *testing.T) {
+	overrides, err := NewConfigBuilder().
+		SetModel("gpt-5.2").
+		SetApprovalPolicy(ApprovalPolicyOnRequest).
+		SetSandbox(SandboxModeWorkspaceWrite).
+		SetMCPServer("search", "search-mcp", "--port", "4000").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := []string{
+		"model=gpt-5.2",
+		"approval_policy=on-request",
+		"sandbox_mode=workspace-write",
+		"mcp_servers.search.command=search-mcp",
+		`mcp_servers.search.args=["--port", "4000"]`,
+	}
+	if !reflect.DeepEqual(overrides, want) {
+		t.Fatalf("overrides = %v, want %v", overrides, want)
+	}
+}
+
+func TestConfigBuilderRejectsUnknownApprovalPolicy(t *testing.T) {
+	_, err := NewConfigBuilder().SetApprovalPolicy("whenever").Build()
+	if err == nil {
+		t.Fatal("Build: want error for unknown approval policy")
+	}
+}
+
+func TestConfigBuilderRejectsEmptyModel(t *testing.T) {
+	_, err := NewConfigBuilder().SetModel("").Build()
+	if err == nil {
+		t.Fatal("Build: want error for empty model")
+	}
+}
+
+func TestConfigBuilderKeepsFirstError(t *testing.T) {
+	_, err := NewConfigBuilder().SetModel("").SetSandbox("bogus").Build()
+	if err == nil {
+		t.Fatal("Build: want error")
+	}
+	if got := err.Error(); got != "codex: SetModel: model must not be empty" {
+		t.Fatalf("err = %q, want the first failure to win", got)
+	}
+}
@@ -0,0 +1,37 @@
+package codex
+
+import "context"
+
+// contextKey is an unexported type for this package's context.Context keys,
+// so they can't collide with keys set by other packages.
+type contextKey int
+
+const (
+	threadIDContextKey contextKey = iota
+	turnIDContextKey
+)
+
+// withThreadAndTurnID returns a copy of ctx carrying threadID and turnID,
+// retrievable later via ThreadIDFromContext and TurnIDFromContext.
+func withThreadAndTurnID(ctx context.Context, threadID, turnID string) context.Context {
+	ctx = context.WithValue(ctx, threadIDContextKey, threadID)
+	ctx = context.WithValue(ctx, turnIDContextKey, turnID)
+	return ctx
+}
+
+// ThreadIDFromContext returns the thread id the SDK attached to ctx, or ""
+// if none is attached. The context passed to every ApprovalHandler method
+// carries the thread id of the turn the server-initiated request belongs
+// to, so a handler can log or route its decision per conversation without
+// digging the id out of its params argument.
+func ThreadIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(threadIDContextKey).(string)
+	return id
+}
+
+// TurnIDFromContext returns the turn id the SDK attached to ctx, or "" if
+// none is attached. See ThreadIDFromContext.
+func TurnIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(turnIDContextKey).(string)
+	return id
+}
@@ -0,0 +1,26 @@
+package codex
+
+import (
+	"context"
+	"testing"
+)
+
+func TestThreadAndTurnIDFromContextUnset(t *testing.T) {
+	ctx := context.Background()
+	if got := ThreadIDFromContext(ctx); got != "" {
+		t.Fatalf("ThreadIDFromContext = %q, want empty", got)
+	}
+	if got := TurnIDFromContext(ctx); got != "" {
+		t.Fatalf("TurnIDFromContext = %q, want empty", got)
+	}
+}
+
+func TestWithThreadAndTurnID(t *testing.T) {
+	ctx := withThreadAndTurnID(context.Background(), "thread-1", "turn-1")
+	if got := ThreadIDFromContext(ctx); got != "thread-1" {
+		t.Fatalf("ThreadIDFromContext = %q, want thread-1", got)
+	}
+	if got := TurnIDFromContext(ctx); got != "turn-1" {
+		t.Fatalf("TurnIDFromContext = %q, want turn-1", got)
+	}
+}
@@ -0,0 +1,56 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Conversation is a serializable snapshot of a Thread, capturing just
+// enough (its id, on-disk rollout path, and the model/cwd the app-server
+// confirmed for it) to resume it later without the caller separately
+// stashing those fields and reconstructing a ThreadResumeOptions by hand.
+type Conversation struct {
+	ThreadID         string `json:"threadId"`
+	RolloutPath      string `json:"rolloutPath,omitempty"`
+	Model            string `json:"model,omitempty"`
+	WorkingDirectory string `json:"workingDirectory,omitempty"`
+}
+
+// NewConversation captures t's resumable state.
+func NewConversation(t *Thread) Conversation {
+	return Conversation{
+		ThreadID:         t.id,
+		RolloutPath:      t.rolloutPath,
+		Model:            t.model,
+		WorkingDirectory: t.workingDirectory,
+	}
+}
+
+// Save writes c to w as JSON.
+func (c Conversation) Save(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		return fmt.Errorf("codex: Conversation.Save: %w", err)
+	}
+	return nil
+}
+
+// LoadConversation reads a Conversation previously written by Save from r
+// and resumes it against codex, preferring RolloutPath when set (the same
+// preference ThreadResumeOptions itself gives a rollout path over a bare
+// thread id).
+func LoadConversation(ctx context.Context, r io.Reader, codex *Codex) (*Thread, error) {
+	var c Conversation
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("codex: LoadConversation: decode: %w", err)
+	}
+	if c.ThreadID == "" && c.RolloutPath == "" {
+		return nil, fmt.Errorf("codex: LoadConversation: conversation has neither a thread id nor a rollout path")
+	}
+	thread, err := codex.ResumeThread(ctx, c.ThreadID, ThreadResumeOptions{Path: c.RolloutPath})
+	if err != nil {
+		return nil, fmt.Errorf("codex: LoadConversation: %w", err)
+	}
+	return thread, nil
+}
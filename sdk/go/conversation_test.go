@@ -0,0 +1,64 @@
+package codex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestConversationSaveAndLoadRoundTrip(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	codex := &Codex{client: client}
+	thread := &Thread{
+		codex:            codex,
+		id:               "thread-1",
+		model:            "gpt-5-codex",
+		workingDirectory: "/work",
+		rolloutPath:      "/home/user/.codex/sessions/thread-1.jsonl",
+	}
+
+	var buf bytes.Buffer
+	if err := NewConversation(thread).Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	transport.push(`{"id":1,"result":{"thread":{"id":"thread-1","model":"gpt-5-codex","cwd":"/work"}}}`)
+
+	resumed, err := LoadConversation(context.Background(), &buf, codex)
+	if err != nil {
+		t.Fatalf("LoadConversation: %v", err)
+	}
+	if resumed.ID() != "thread-1" {
+		t.Fatalf("ID() = %q, want thread-1", resumed.ID())
+	}
+
+	var req struct {
+		Params struct {
+			ThreadID string `json:"threadId"`
+			Path     string `json:"path"`
+		} `json:"params"`
+	}
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Params.Path != "/home/user/.codex/sessions/thread-1.jsonl" {
+		t.Fatalf("Path = %q, want the saved rollout path", req.Params.Path)
+	}
+}
+
+func TestLoadConversationRejectsMissingThreadIDAndRolloutPath(t *testing.T) {
+	_, err := LoadConversation(context.Background(), strings.NewReader(`{}`), &Codex{})
+	if err == nil {
+		t.Fatal("LoadConversation: want error when the conversation has neither id nor rollout path")
+	}
+}
@@ -0,0 +1,11 @@
+// Package codex is the Go SDK for embedding the Codex agent in workflows and apps.
+//
+// It spawns the codex CLI's app-server and speaks its JSON-RPC protocol over
+// stdio. The low-level protocol client lives in the rpc subpackage; this
+// package provides the Codex/Thread ergonomics most callers want.
+//
+//	c, err := codex.New(codex.Options{})
+//	thread, err := c.StartThread(ctx, codex.ThreadStartOptions{})
+//	result, err := thread.Run(ctx, codex.TextInput("Diagnose the failing test"))
+//	fmt.Println(result.FinalResponse)
+package codex
@@ -0,0 +1,81 @@
+package codex
+
+import "fmt"
+
+// Effort is how much reasoning effort the model should spend on a turn.
+// It's a plain string under the hood, so a caller can still pass a raw
+// value the SDK doesn't know about yet (for forward-compat with a newer
+// app-server); set TurnOptions.StrictEnums to reject anything outside the
+// known constants instead.
+type Effort string
+
+const (
+	EffortLow    Effort = "low"
+	EffortMedium Effort = "medium"
+	EffortHigh   Effort = "high"
+)
+
+// Summary is how verbose the model's reasoning summary should be. Like
+// Effort, it's a plain string so forward-compat values still pass through
+// unless TurnOptions.StrictEnums is set.
+type Summary string
+
+const (
+	SummaryAuto     Summary = "auto"
+	SummaryConcise  Summary = "concise"
+	SummaryDetailed Summary = "detailed"
+)
+
+// EnumValidationError is returned by RunStreamed/RunWithOptions when
+// TurnOptions.StrictEnums is set and a TurnOptions field holds a value
+// outside its known constants.
+type EnumValidationError struct {
+	// Field is the TurnOptions field that failed validation, such as
+	// "Effort" or "Summary".
+	Field string
+	// Value is the offending value.
+	Value string
+}
+
+func (e *EnumValidationError) Error() string {
+	return fmt.Sprintf("codex: invalid %s %q", e.Field, e.Value)
+}
+
+var knownEfforts = map[Effort]bool{EffortLow: true, EffortMedium: true, EffortHigh: true}
+
+var knownSummaries = map[Summary]bool{SummaryAuto: true, SummaryConcise: true, SummaryDetailed: true}
+
+var knownApprovalPolicies = map[ApprovalPolicy]bool{
+	ApprovalPolicyUntrusted: true,
+	ApprovalPolicyOnFailure: true,
+	ApprovalPolicyOnRequest: true,
+	ApprovalPolicyNever:     true,
+}
+
+// validateEnums checks opts.Effort, opts.Summary, and opts.ApprovalPolicy
+// against their known constants when opts.StrictEnums is set, returning an
+// *EnumValidationError for the first one that doesn't match. It's a no-op
+// when StrictEnums is false, the default, so unrecognized values silently
+// pass through to the app-server as they always have.
+func validateEnums(opts TurnOptions) error {
+	if !opts.StrictEnums {
+		return nil
+	}
+	return checkEnums(opts)
+}
+
+// checkEnums is the unconditional check behind validateEnums, also used by
+// Thread.ValidateTurn, which always wants the check applied regardless of
+// TurnOptions.StrictEnums.
+func checkEnums(opts TurnOptions) error {
+	if opts.Effort != "" && !knownEfforts[opts.Effort] {
+		return &EnumValidationError{Field: "Effort", Value: string(opts.Effort)}
+	}
+	if opts.Summary != "" && !knownSummaries[opts.Summary] {
+		return &EnumValidationError{Field: "Summary", Value: string(opts.Summary)}
+	}
+	if opts.ApprovalPolicy != "" && !knownApprovalPolicies[opts.ApprovalPolicy] {
+		return &EnumValidationError{Field: "ApprovalPolicy", Value: string(opts.ApprovalPolicy)}
+	}
+	return nil
+}
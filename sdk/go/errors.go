@@ -0,0 +1,110 @@
+package codex
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDraining is returned by StartThread, ResumeThread, and
+// Thread.Run/RunStreamed once BeginDrain has been called, to reject new
+// work while turns already in flight finish.
+var ErrDraining = errors.New("codex: client is draining")
+
+// ErrPathNotAllowed is returned by Thread.Run/RunStreamed when an Input
+// references a local path outside Options.AllowedInputRoots.
+var ErrPathNotAllowed = errors.New("codex: path is outside allowed input roots")
+
+// ErrNoResponse is returned by New when Options.FirstByteTimeout elapses
+// without the app-server subprocess producing any output at all, usually
+// a sign it never started rather than that it's merely slow to answer
+// initialize.
+var ErrNoResponse = errors.New("codex: app-server produced no output before FirstByteTimeout")
+
+// ErrThreadIDNotFound is returned when a thread/start or thread/resume
+// response is missing the thread id the rest of the SDK needs to address
+// it. Wrapped with fmt.Errorf("...: %w", ErrThreadIDNotFound) so callers
+// can check it with errors.Is instead of matching on message text.
+var ErrThreadIDNotFound = errors.New("codex: response missing thread id")
+
+// ErrNotImplemented is returned by a handler that has no way to satisfy a
+// request it was asked to answer, such as AutoApproveHandler being asked
+// to answer a tool's interactive question.
+var ErrNotImplemented = errors.New("codex: not implemented")
+
+// ErrInvalidSandboxPolicy is returned by ValidateSandbox when the
+// app-server reports the policy as unenforceable on the current machine.
+var ErrInvalidSandboxPolicy = errors.New("codex: sandbox policy is invalid")
+
+// ErrMalformedRolloutPath is returned by ResumeThreadFromPath when path's
+// filename doesn't embed a recognizable thread id, as every rollout file
+// Codex itself writes does (rollout-<timestamp>-<thread id>.jsonl).
+var ErrMalformedRolloutPath = errors.New("codex: rollout path does not embed a thread id")
+
+// ErrNoFinalResponse is returned by Run/RunWithOptions when
+// TurnOptions.RequireFinalResponse is set and the turn completed without
+// producing an assistant message, for example one that only ran tool
+// calls. TurnResult is still returned alongside it, so callers that want
+// the partial result (items, usage) rather than just the error can still
+// get at it.
+var ErrNoFinalResponse = errors.New("codex: turn completed without a final assistant response")
+
+// ErrThreadDeleted is returned by a Thread's methods once Delete has
+// removed its server-side state. The Thread value itself remains safe to
+// hold onto (for example to read its ID for logging) but can no longer be
+// used to run turns or fetch history.
+var ErrThreadDeleted = errors.New("codex: thread has been deleted")
+
+// ErrTurnFailed is returned when a turn/completed notification reports a
+// non-retryable failure. The underlying app-server message, if any, is
+// appended to the wrapping error's text.
+var ErrTurnFailed = errors.New("codex: turn failed")
+
+// TurnError is the concrete error notificationError returns for a
+// non-retryable turn failure, preserving the app-server's error code
+// alongside its message so callers can branch on the failure kind with
+// errors.As instead of parsing ErrTurnFailed's formatted text. It unwraps
+// to ErrTurnFailed, so existing errors.Is(err, ErrTurnFailed) checks keep
+// working unchanged.
+type TurnError struct {
+	// Code is the app-server-reported error code, or 0 if it didn't report
+	// one.
+	Code int
+	// Message is the app-server-reported error message, or "" if absent.
+	Message string
+	// RateLimits is the most recent account/rateLimits/updated snapshot
+	// observed during the turn before it failed, or nil if none arrived.
+	// Set by Thread.Run/RunStreamed from the enclosing TurnResult.RateLimits;
+	// notificationError itself never populates it.
+	RateLimits *RateLimitInfo
+}
+
+func (e *TurnError) Error() string {
+	if e.Message == "" {
+		return ErrTurnFailed.Error()
+	}
+	return fmt.Sprintf("%s: %s (code %d)", ErrTurnFailed, e.Message, e.Code)
+}
+
+func (e *TurnError) Unwrap() error {
+	return ErrTurnFailed
+}
+
+// ErrPingTimeout is returned by Codex.Ping when ctx is done before the
+// app-server answers.
+var ErrPingTimeout = errors.New("codex: ping timed out")
+
+// PingTimeoutError is the concrete error Ping returns when ctx expires
+// before the app-server responds, carrying how long it waited. It unwraps
+// to ErrPingTimeout.
+type PingTimeoutError struct {
+	Elapsed time.Duration
+}
+
+func (e *PingTimeoutError) Error() string {
+	return fmt.Sprintf("%s after %s", ErrPingTimeout, e.Elapsed)
+}
+
+func (e *PingTimeoutError) Unwrap() error {
+	return ErrPingTimeout
+}
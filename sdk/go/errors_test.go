@@ -0,0 +1,74 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestAutoApproveHandlerToolInputErrorIsNotImplemented(t *testing.T) {
+	_, err := AutoApproveHandler{}.ItemToolRequestUserInput(context.Background(), ToolUserInputApprovalParams{Question: "what should I do?"})
+	if !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("ItemToolRequestUserInput error = %v, want ErrNotImplemented", err)
+	}
+}
+
+func TestAutoApproveHandlerReturnsConfiguredToolInputAnswer(t *testing.T) {
+	answer, err := AutoApproveHandler{ToolInputAnswer: "yes, continue"}.ItemToolRequestUserInput(context.Background(), ToolUserInputApprovalParams{Question: "proceed?"})
+	if err != nil {
+		t.Fatalf("ItemToolRequestUserInput: %v", err)
+	}
+	if answer != "yes, continue" {
+		t.Fatalf("answer = %q, want %q", answer, "yes, continue")
+	}
+}
+
+func TestValidateSandboxWrapsErrInvalidSandboxPolicy(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	transport.push(`{"id":1,"result":{"valid":false,"reason":"no writable root configured"}}`)
+
+	err := c.ValidateSandbox(context.Background(), map[string]any{"mode": "workspace-write"})
+	if !errors.Is(err, ErrInvalidSandboxPolicy) {
+		t.Fatalf("ValidateSandbox error = %v, want ErrInvalidSandboxPolicy", err)
+	}
+}
+
+func TestNotificationErrorWrapsErrTurnFailed(t *testing.T) {
+	err := notificationError(rpc.Notification{
+		Method: "turn/completed",
+		Params: map[string]any{
+			"turn": map[string]any{
+				"status": "failed",
+				"error":  map[string]any{"message": "model unavailable"},
+			},
+		},
+	})
+	if !errors.Is(err, ErrTurnFailed) {
+		t.Fatalf("notificationError = %v, want it to wrap ErrTurnFailed", err)
+	}
+}
+
+func TestNotificationErrorCarriesAppServerCode(t *testing.T) {
+	err := notificationError(rpc.Notification{
+		Method: "turn/completed",
+		Params: map[string]any{
+			"turn": map[string]any{
+				"status": "failed",
+				"error":  map[string]any{"message": "rate limited", "code": 429.0},
+			},
+		},
+	})
+	var turnErr *TurnError
+	if !errors.As(err, &turnErr) {
+		t.Fatalf("notificationError = %v, want *TurnError", err)
+	}
+	if turnErr.Code != 429 || turnErr.Message != "rate limited" {
+		t.Fatalf("turnErr = %+v, want Code 429 and Message %q", turnErr, "rate limited")
+	}
+}
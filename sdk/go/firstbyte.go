@@ -0,0 +1,38 @@
+package codex
+
+import (
+	"sync"
+	"time"
+)
+
+// firstByteWatcher signals the first time any bytes are read from the
+// app-server transport. New races this against Options.FirstByteTimeout to
+// detect a subprocess that hangs before producing any output at all, which
+// would otherwise block forever waiting on initialize's response.
+type firstByteWatcher struct {
+	ready chan struct{}
+	once  sync.Once
+}
+
+func newFirstByteWatcher() *firstByteWatcher {
+	return &firstByteWatcher{ready: make(chan struct{})}
+}
+
+// onRead is passed as a TapTransport read callback.
+func (w *firstByteWatcher) onRead([]byte) {
+	w.once.Do(func() { close(w.ready) })
+}
+
+// timedOut returns a channel that closes after timeout, unless a first byte
+// arrives first, in which case it never fires.
+func (w *firstByteWatcher) timedOut(timeout time.Duration) <-chan struct{} {
+	out := make(chan struct{})
+	go func() {
+		select {
+		case <-w.ready:
+		case <-time.After(timeout):
+			close(out)
+		}
+	}()
+	return out
+}
@@ -0,0 +1,27 @@
+package codex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstByteWatcherTimesOutWithoutAnyRead(t *testing.T) {
+	w := newFirstByteWatcher()
+
+	select {
+	case <-w.timedOut(10 * time.Millisecond):
+	case <-time.After(time.Second):
+		t.Fatalf("timedOut never fired")
+	}
+}
+
+func TestFirstByteWatcherSkipsTimeoutAfterRead(t *testing.T) {
+	w := newFirstByteWatcher()
+	w.onRead([]byte("line"))
+
+	select {
+	case <-w.timedOut(10 * time.Millisecond):
+		t.Fatalf("timedOut fired despite a prior read")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,272 @@
+package codex
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Input is a single piece of content sent to the agent as part of a turn.
+// The app-server currently accepts Type "text", "image", "localImage",
+// "skill", "localFile", and "remoteFile"; see the matching constructor for
+// each.
+type Input struct {
+	Type string
+	Text string
+	Path string
+	URL  string
+}
+
+// TextInput returns a plain-text Input.
+func TextInput(text string) Input {
+	return Input{Type: "text", Text: text}
+}
+
+// ImageInput returns an Input referencing an image by URL.
+func ImageInput(url string) Input {
+	return Input{Type: "image", URL: url}
+}
+
+// LocalImageInput returns an Input referencing an image on the local
+// filesystem. The app-server reads the file from path.
+func LocalImageInput(path string) Input {
+	return Input{Type: "localImage", Path: path}
+}
+
+// allowedImageMIMETypes are the mime types ImageBytesInput accepts.
+var allowedImageMIMETypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ImageBytesInput returns an Input for an in-memory image (a screenshot
+// buffer, a generated chart, ...), base64-encoded into a data URL so
+// callers don't need to write a temp file just to attach it. mimeType must
+// be one of image/png, image/jpeg, image/gif, or image/webp; anything else
+// returns an error, since the app-server (and the model behind it) only
+// understands those.
+func ImageBytesInput(data []byte, mimeType string) (Input, error) {
+	if len(data) == 0 {
+		return Input{}, fmt.Errorf("codex: ImageBytesInput: data is empty")
+	}
+	if !allowedImageMIMETypes[mimeType] {
+		return Input{}, fmt.Errorf("codex: ImageBytesInput: unsupported mime type %q, want one of image/png, image/jpeg, image/gif, image/webp", mimeType)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	return Input{Type: "image", URL: fmt.Sprintf("data:%s;base64,%s", mimeType, encoded)}, nil
+}
+
+// SkillInput returns an Input that invokes a named skill.
+func SkillInput(name string) Input {
+	return Input{Type: "skill", Text: name}
+}
+
+// FileInput returns an Input attaching a local, non-image file (PDF, CSV,
+// log, etc.) as context, read by the app-server from path. path is stat'd
+// up front so an obvious "file not found" is caught here rather than deep
+// inside the app-server; it returns an error if path is empty or doesn't
+// exist.
+func FileInput(path string) (Input, error) {
+	if path == "" {
+		return Input{}, fmt.Errorf("codex: FileInput: path is empty")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return Input{}, fmt.Errorf("codex: FileInput: stat %q: %w", path, err)
+	}
+	return Input{Type: "localFile", Path: path}, nil
+}
+
+// RemoteFileInput returns an Input referencing a file by URL, for
+// app-servers that accept fetching file context remotely rather than only
+// from the local filesystem.
+func RemoteFileInput(url string) Input {
+	return Input{Type: "remoteFile", URL: url}
+}
+
+// ImageBatchLimits bounds a batch of images built by ImageInputsWithLimits
+// or LocalImageInputsWithLimits. Either field may be left at zero to skip
+// that check.
+type ImageBatchLimits struct {
+	// MaxCount caps how many images a single call accepts.
+	MaxCount int
+	// MaxLocalFileBytes caps the size of any one local image file. It has
+	// no effect on ImageInputsWithLimits, which references remote URLs the
+	// SDK can't stat.
+	MaxLocalFileBytes int64
+}
+
+// DefaultImageBatchLimits is applied by ImageInputs and LocalImageInputs.
+var DefaultImageBatchLimits = ImageBatchLimits{MaxCount: 16}
+
+// ImageInputs returns one Input per url, enforcing DefaultImageBatchLimits.
+func ImageInputs(urls ...string) ([]Input, error) {
+	return ImageInputsWithLimits(DefaultImageBatchLimits, urls...)
+}
+
+// ImageInputsWithLimits is ImageInputs with caller-supplied limits.
+func ImageInputsWithLimits(limits ImageBatchLimits, urls ...string) ([]Input, error) {
+	if err := limits.checkCount(len(urls)); err != nil {
+		return nil, err
+	}
+	inputs := make([]Input, len(urls))
+	for i, url := range urls {
+		inputs[i] = ImageInput(url)
+	}
+	return inputs, nil
+}
+
+// LocalImageInputs returns one Input per path, enforcing
+// DefaultImageBatchLimits.
+func LocalImageInputs(paths ...string) ([]Input, error) {
+	return LocalImageInputsWithLimits(DefaultImageBatchLimits, paths...)
+}
+
+// LocalImageInputsWithLimits is LocalImageInputs with caller-supplied
+// limits. When limits.MaxLocalFileBytes is set, each path is stat'd up
+// front so an oversized attachment is rejected before the turn starts
+// rather than failing deep inside the app-server.
+func LocalImageInputsWithLimits(limits ImageBatchLimits, paths ...string) ([]Input, error) {
+	if err := limits.checkCount(len(paths)); err != nil {
+		return nil, err
+	}
+	inputs := make([]Input, len(paths))
+	for i, path := range paths {
+		if limits.MaxLocalFileBytes > 0 {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("codex: stat local image %q: %w", path, err)
+			}
+			if info.Size() > limits.MaxLocalFileBytes {
+				return nil, fmt.Errorf("codex: local image %q is %d bytes, exceeds limit of %d", path, info.Size(), limits.MaxLocalFileBytes)
+			}
+		}
+		inputs[i] = LocalImageInput(path)
+	}
+	return inputs, nil
+}
+
+func (l ImageBatchLimits) checkCount(n int) error {
+	if l.MaxCount > 0 && n > l.MaxCount {
+		return fmt.Errorf("codex: %d images exceeds batch limit of %d", n, l.MaxCount)
+	}
+	return nil
+}
+
+// validatePath checks i.Path against roots, if any are configured,
+// returning ErrPathNotAllowed if it falls outside all of them. Inputs with
+// no local path (text, remote image URL, skill) are always allowed.
+func (i Input) validatePath(roots []string) error {
+	if i.Path == "" || len(roots) == 0 {
+		return nil
+	}
+	abs, err := filepath.Abs(i.Path)
+	if err != nil {
+		return fmt.Errorf("codex: resolve input path %q: %w", i.Path, err)
+	}
+	for _, root := range roots {
+		rootAbs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if abs == rootAbs || strings.HasPrefix(abs, rootAbs+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrPathNotAllowed, i.Path)
+}
+
+// InputBuilder assembles a multi-part Input slice (text, images, skills)
+// with a fluent interface, instead of callers hand-assembling
+// []Input{TextInput(...), ImageInput(...), ...} themselves. Zero value is
+// ready to use; setters chain and Build reports the first invalid value.
+//
+// The app-server resolves UserInput::Skill entries against the rest of the
+// turn's inputs regardless of where they fall in the list, so there's no
+// ordering constraint to enforce here between Skill and the other parts.
+type InputBuilder struct {
+	inputs []Input
+	err    error
+}
+
+// NewInputBuilder returns an empty InputBuilder.
+func NewInputBuilder() *InputBuilder {
+	return &InputBuilder{}
+}
+
+// Text appends a plain-text part.
+func (b *InputBuilder) Text(text string) *InputBuilder {
+	if text == "" {
+		b.fail(fmt.Errorf("codex: InputBuilder.Text: text must not be empty"))
+		return b
+	}
+	b.inputs = append(b.inputs, TextInput(text))
+	return b
+}
+
+// Image appends an image part referenced by URL.
+func (b *InputBuilder) Image(url string) *InputBuilder {
+	if url == "" {
+		b.fail(fmt.Errorf("codex: InputBuilder.Image: url must not be empty"))
+		return b
+	}
+	b.inputs = append(b.inputs, ImageInput(url))
+	return b
+}
+
+// LocalImage appends an image part read from the local filesystem.
+func (b *InputBuilder) LocalImage(path string) *InputBuilder {
+	if path == "" {
+		b.fail(fmt.Errorf("codex: InputBuilder.LocalImage: path must not be empty"))
+		return b
+	}
+	b.inputs = append(b.inputs, LocalImageInput(path))
+	return b
+}
+
+// Skill appends a part invoking a named skill.
+func (b *InputBuilder) Skill(name string) *InputBuilder {
+	if name == "" {
+		b.fail(fmt.Errorf("codex: InputBuilder.Skill: name must not be empty"))
+		return b
+	}
+	b.inputs = append(b.inputs, SkillInput(name))
+	return b
+}
+
+func (b *InputBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the assembled inputs in the order they were added, or the
+// first validation error encountered by a setter.
+func (b *InputBuilder) Build() ([]Input, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return append([]Input(nil), b.inputs...), nil
+}
+
+func (i Input) toParam() map[string]any {
+	switch i.Type {
+	case "text":
+		return map[string]any{"type": "text", "text": i.Text}
+	case "image":
+		return map[string]any{"type": "image", "url": i.URL}
+	case "localImage":
+		return map[string]any{"type": "localImage", "path": i.Path}
+	case "skill":
+		return map[string]any{"type": "skill", "name": i.Text}
+	case "localFile":
+		return map[string]any{"type": "localFile", "path": i.Path}
+	case "remoteFile":
+		return map[string]any{"type": "remoteFile", "url": i.URL}
+	default:
+		return map[string]any{"type": i.Type}
+	}
+}
@@ -0,0 +1,185 @@
+package codex
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInputValidatePathAllowsNoRoots(t *testing.T) {
+	if err := LocalImageInput("/etc/passwd").validatePath(nil); err != nil {
+		t.Fatalf("validatePath with no roots configured: %v", err)
+	}
+}
+
+func TestInputValidatePathRejectsOutsideRoots(t *testing.T) {
+	err := LocalImageInput("/etc/passwd").validatePath([]string{"/workspace"})
+	if !errors.Is(err, ErrPathNotAllowed) {
+		t.Fatalf("validatePath = %v, want ErrPathNotAllowed", err)
+	}
+}
+
+func TestInputValidatePathAllowsInsideRoots(t *testing.T) {
+	if err := LocalImageInput("/workspace/screenshots/a.png").validatePath([]string{"/workspace"}); err != nil {
+		t.Fatalf("validatePath: %v", err)
+	}
+}
+
+func TestInputValidatePathIgnoresNonPathInputs(t *testing.T) {
+	if err := TextInput("hello").validatePath([]string{"/workspace"}); err != nil {
+		t.Fatalf("validatePath on a text input: %v", err)
+	}
+}
+
+func TestImageInputsBuildsOneInputPerURL(t *testing.T) {
+	inputs, err := ImageInputs("https://example.com/a.png", "https://example.com/b.png")
+	if err != nil {
+		t.Fatalf("ImageInputs: %v", err)
+	}
+	if len(inputs) != 2 || inputs[0].URL != "https://example.com/a.png" || inputs[1].URL != "https://example.com/b.png" {
+		t.Fatalf("inputs = %+v", inputs)
+	}
+}
+
+func TestImageInputsWithLimitsRejectsTooManyImages(t *testing.T) {
+	_, err := ImageInputsWithLimits(ImageBatchLimits{MaxCount: 1}, "https://example.com/a.png", "https://example.com/b.png")
+	if err == nil {
+		t.Fatal("ImageInputsWithLimits: want error when the batch exceeds MaxCount")
+	}
+}
+
+func TestImageBytesInputEncodesDataURL(t *testing.T) {
+	input, err := ImageBytesInput([]byte("\x89PNG"), "image/png")
+	if err != nil {
+		t.Fatalf("ImageBytesInput: %v", err)
+	}
+	if input.Type != "image" {
+		t.Fatalf("Type = %q, want image", input.Type)
+	}
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("\x89PNG"))
+	if input.URL != want {
+		t.Fatalf("URL = %q, want %q", input.URL, want)
+	}
+}
+
+func TestImageBytesInputRejectsUnsupportedMIMEType(t *testing.T) {
+	if _, err := ImageBytesInput([]byte("data"), "application/pdf"); err == nil {
+		t.Fatal("ImageBytesInput: want error for an unsupported mime type")
+	}
+}
+
+func TestImageBytesInputRejectsEmptyData(t *testing.T) {
+	if _, err := ImageBytesInput(nil, "image/png"); err == nil {
+		t.Fatal("ImageBytesInput: want error for empty data")
+	}
+}
+
+func TestLocalImageInputsWithLimitsRejectsOversizedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.png")
+	if err := os.WriteFile(path, make([]byte, 1024), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	_, err := LocalImageInputsWithLimits(ImageBatchLimits{MaxLocalFileBytes: 100}, path)
+	if err == nil {
+		t.Fatal("LocalImageInputsWithLimits: want error when a file exceeds MaxLocalFileBytes")
+	}
+}
+
+func TestFileInputRejectsEmptyPath(t *testing.T) {
+	if _, err := FileInput(""); err == nil {
+		t.Fatal("FileInput: want error for an empty path")
+	}
+}
+
+func TestFileInputRejectsMissingFile(t *testing.T) {
+	if _, err := FileInput(filepath.Join(t.TempDir(), "missing.pdf")); err == nil {
+		t.Fatal("FileInput: want error for a file that doesn't exist")
+	}
+}
+
+func TestFileInputAcceptsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.csv")
+	if err := os.WriteFile(path, []byte("a,b,c"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	input, err := FileInput(path)
+	if err != nil {
+		t.Fatalf("FileInput: %v", err)
+	}
+	if input.Type != "localFile" || input.Path != path {
+		t.Fatalf("input = %+v, want localFile input for %q", input, path)
+	}
+}
+
+func TestInputBuilderBuildsMixedInputsInOrder(t *testing.T) {
+	inputs, err := NewInputBuilder().
+		Skill("review").
+		Text("look at this").
+		Image("https://example.com/a.png").
+		LocalImage("/tmp/b.png").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := []Input{
+		SkillInput("review"),
+		TextInput("look at this"),
+		ImageInput("https://example.com/a.png"),
+		LocalImageInput("/tmp/b.png"),
+	}
+	if len(inputs) != len(want) {
+		t.Fatalf("len(inputs) = %d, want %d", len(inputs), len(want))
+	}
+	for i, in := range inputs {
+		if in != want[i] {
+			t.Fatalf("inputs[%d] = %+v, want %+v", i, in, want[i])
+		}
+	}
+}
+
+func TestInputBuilderRejectsEmptyText(t *testing.T) {
+	_, err := NewInputBuilder().Text("").Build()
+	if err == nil {
+		t.Fatal("Build: want an error for an empty Text part")
+	}
+}
+
+func TestInputBuilderReportsFirstError(t *testing.T) {
+	_, err := NewInputBuilder().Text("").Image("").Build()
+	if err == nil {
+		t.Fatal("Build: want an error")
+	}
+	if !strings.Contains(err.Error(), "InputBuilder.Text") {
+		t.Fatalf("err = %v, want the first (Text) setter's error", err)
+	}
+}
+
+func TestRemoteFileInputSetsURL(t *testing.T) {
+	input := RemoteFileInput("https://example.com/report.pdf")
+	if input.Type != "remoteFile" || input.URL != "https://example.com/report.pdf" {
+		t.Fatalf("input = %+v", input)
+	}
+}
+
+func TestLocalImageInputsWithLimitsAllowsFileWithinLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "small.png")
+	if err := os.WriteFile(path, make([]byte, 10), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	inputs, err := LocalImageInputsWithLimits(ImageBatchLimits{MaxLocalFileBytes: 100}, path)
+	if err != nil {
+		t.Fatalf("LocalImageInputsWithLimits: %v", err)
+	}
+	if len(inputs) != 1 || inputs[0].Path != path {
+		t.Fatalf("inputs = %+v", inputs)
+	}
+}
@@ -0,0 +1,129 @@
+package codex
+
+// CommandExecutionItem is the decoded payload of a completed
+// command_execution item: a shell command the agent ran.
+type CommandExecutionItem struct {
+	// ID is the item's id, as reported by the app-server.
+	ID string
+	// Command is the shell command line that was run.
+	Command string
+	// ExitCode is the command's exit status, or 0 if the app-server didn't
+	// report one.
+	ExitCode int
+	// AggregatedOutput is the command's combined stdout/stderr, as the
+	// app-server captured it.
+	AggregatedOutput string
+}
+
+// FileChangeItem is the decoded payload of a completed file_change item: a
+// patch the agent applied.
+type FileChangeItem struct {
+	// ID is the item's id, as reported by the app-server.
+	ID string
+	// Paths lists the files the patch touched.
+	Paths []string
+	// UnifiedDiff is the patch itself, in unified diff format, if the
+	// app-server included it.
+	UnifiedDiff string
+}
+
+// ToolCallItem is the decoded payload of a completed mcp_tool_call item: an
+// invocation of an MCP tool.
+type ToolCallItem struct {
+	// ID is the item's id, as reported by the app-server.
+	ID string
+	// Server is the MCP server the tool belongs to.
+	Server string
+	// Tool is the tool's name.
+	Tool string
+	// Arguments is the raw arguments the agent passed to the tool.
+	Arguments map[string]any
+	// Result is the tool's raw result, if the app-server included one.
+	Result map[string]any
+}
+
+// CommandExecutions decodes every command_execution item completed during
+// the turn, in the order they completed.
+func (r *TurnResult) CommandExecutions() []CommandExecutionItem {
+	var items []CommandExecutionItem
+	for _, raw := range r.Items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if itemType, _ := item["type"].(string); itemType != "command_execution" {
+			continue
+		}
+		items = append(items, decodeCommandExecution(item))
+	}
+	return items
+}
+
+// FileChanges decodes every file_change item completed during the turn, in
+// the order they completed.
+func (r *TurnResult) FileChanges() []FileChangeItem {
+	var items []FileChangeItem
+	for _, raw := range r.Items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if itemType, _ := item["type"].(string); itemType != "file_change" {
+			continue
+		}
+		items = append(items, decodeFileChange(item))
+	}
+	return items
+}
+
+// ToolCalls decodes every mcp_tool_call item completed during the turn, in
+// the order they completed.
+func (r *TurnResult) ToolCalls() []ToolCallItem {
+	var items []ToolCallItem
+	for _, raw := range r.Items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if itemType, _ := item["type"].(string); itemType != "mcp_tool_call" {
+			continue
+		}
+		items = append(items, decodeToolCall(item))
+	}
+	return items
+}
+
+func decodeCommandExecution(item map[string]any) CommandExecutionItem {
+	var e CommandExecutionItem
+	e.ID, _ = item["id"].(string)
+	e.Command, _ = item["command"].(string)
+	if v, ok := item["exitCode"].(float64); ok {
+		e.ExitCode = int(v)
+	}
+	e.AggregatedOutput, _ = item["aggregatedOutput"].(string)
+	return e
+}
+
+func decodeFileChange(item map[string]any) FileChangeItem {
+	var e FileChangeItem
+	e.ID, _ = item["id"].(string)
+	if paths, ok := item["paths"].([]any); ok {
+		for _, p := range paths {
+			if path, ok := p.(string); ok {
+				e.Paths = append(e.Paths, path)
+			}
+		}
+	}
+	e.UnifiedDiff, _ = item["unifiedDiff"].(string)
+	return e
+}
+
+func decodeToolCall(item map[string]any) ToolCallItem {
+	var e ToolCallItem
+	e.ID, _ = item["id"].(string)
+	e.Server, _ = item["server"].(string)
+	e.Tool, _ = item["tool"].(string)
+	e.Arguments, _ = item["arguments"].(map[string]any)
+	e.Result, _ = item["result"].(map[string]any)
+	return e
+}
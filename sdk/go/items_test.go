@@ -0,0 +1,100 @@
+package codex
+
+import (
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestCommandExecutionsDecodesCompletedCommandItems(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{
+				"type":             "command_execution",
+				"id":               "item-1",
+				"command":          "go test ./...",
+				"exitCode":         1.0,
+				"aggregatedOutput": "FAIL",
+			},
+		},
+	}, 0)
+
+	cmds := result.CommandExecutions()
+	if len(cmds) != 1 {
+		t.Fatalf("len(CommandExecutions()) = %d, want 1", len(cmds))
+	}
+	want := CommandExecutionItem{ID: "item-1", Command: "go test ./...", ExitCode: 1, AggregatedOutput: "FAIL"}
+	if cmds[0] != want {
+		t.Fatalf("CommandExecutions()[0] = %+v, want %+v", cmds[0], want)
+	}
+}
+
+func TestFileChangesDecodesCompletedFileChangeItems(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{
+				"type":        "file_change",
+				"id":          "item-2",
+				"paths":       []any{"a.go", "b.go"},
+				"unifiedDiff": "--- a/a.go\n+++ b/a.go\n",
+			},
+		},
+	}, 0)
+
+	changes := result.FileChanges()
+	if len(changes) != 1 {
+		t.Fatalf("len(FileChanges()) = %d, want 1", len(changes))
+	}
+	if len(changes[0].Paths) != 2 || changes[0].Paths[0] != "a.go" || changes[0].Paths[1] != "b.go" {
+		t.Fatalf("Paths = %v, want [a.go b.go]", changes[0].Paths)
+	}
+	if changes[0].UnifiedDiff == "" {
+		t.Fatal("UnifiedDiff is empty")
+	}
+}
+
+func TestToolCallsDecodesCompletedMCPToolCallItems(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{
+				"type":      "mcp_tool_call",
+				"id":        "item-3",
+				"server":    "filesystem",
+				"tool":      "read_file",
+				"arguments": map[string]any{"path": "a.go"},
+				"result":    map[string]any{"contents": "package codex"},
+			},
+		},
+	}, 0)
+
+	calls := result.ToolCalls()
+	if len(calls) != 1 {
+		t.Fatalf("len(ToolCalls()) = %d, want 1", len(calls))
+	}
+	if calls[0].Server != "filesystem" || calls[0].Tool != "read_file" {
+		t.Fatalf("calls[0] = %+v, want Server filesystem, Tool read_file", calls[0])
+	}
+	if calls[0].Arguments["path"] != "a.go" {
+		t.Fatalf("Arguments = %v, want path=a.go", calls[0].Arguments)
+	}
+}
+
+func TestTypedItemAccessorsIgnoreOtherItemTypes(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"type": "agent_message", "text": "hi"},
+		},
+	}, 0)
+
+	if len(result.CommandExecutions()) != 0 || len(result.FileChanges()) != 0 || len(result.ToolCalls()) != 0 {
+		t.Fatal("typed accessors should ignore an agent_message item")
+	}
+}
@@ -0,0 +1,54 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+)
+
+// Model describes one model the app-server can run turns against, as
+// reported by the "models" RPC.
+type Model struct {
+	// ID is the model's identifier, as passed to ThreadStartOptions.
+	ID string
+	// Title is the model's human-readable display name.
+	Title string
+	// ContextWindow is the model's context window size in tokens, or 0 if
+	// the app-server didn't report one.
+	ContextWindow int
+	// SupportedEfforts lists the reasoning-effort levels this model
+	// accepts, if the app-server reported any.
+	SupportedEfforts []string
+}
+
+// ListModels asks the app-server which models are available, so callers
+// don't have to make the "models" RPC call and decode its raw JSON
+// themselves.
+func (c *Codex) ListModels(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Models []modelWire `json:"models"`
+	}
+	if err := c.currentClient().Call("models", nil, &resp); err != nil {
+		return nil, fmt.Errorf("codex: models: %w", err)
+	}
+	models := make([]Model, len(resp.Models))
+	for i, m := range resp.Models {
+		models[i] = m.toModel()
+	}
+	return models, nil
+}
+
+type modelWire struct {
+	ID               string   `json:"id"`
+	Title            string   `json:"title"`
+	ContextWindow    int      `json:"contextWindow"`
+	SupportedEfforts []string `json:"supportedEfforts"`
+}
+
+func (m modelWire) toModel() Model {
+	return Model{
+		ID:               m.ID,
+		Title:            m.Title,
+		ContextWindow:    m.ContextWindow,
+		SupportedEfforts: m.SupportedEfforts,
+	}
+}
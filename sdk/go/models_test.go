@@ -0,0 +1,34 @@
+package codex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestListModelsDecodesModelsResponse(t *testing.T) {
+	transport := rpc.NewReplayTransport([]rpc.TranscriptEntry{
+		{Direction: rpc.Sent, Line: `{"id":1,"method":"models","params":null}`},
+		{Direction: rpc.Received, Line: `{"id":1,"result":{"models":[{"id":"gpt-5-codex","title":"GPT-5 Codex","contextWindow":400000,"supportedEfforts":["low","high"]}]}}`},
+	})
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models) != 1 {
+		t.Fatalf("len(models) = %d, want 1", len(models))
+	}
+	want := Model{ID: "gpt-5-codex", Title: "GPT-5 Codex", ContextWindow: 400000, SupportedEfforts: []string{"low", "high"}}
+	got := models[0]
+	if got.ID != want.ID || got.Title != want.Title || got.ContextWindow != want.ContextWindow {
+		t.Fatalf("models[0] = %+v, want %+v", got, want)
+	}
+	if len(got.SupportedEfforts) != 2 || got.SupportedEfforts[0] != "low" || got.SupportedEfforts[1] != "high" {
+		t.Fatalf("SupportedEfforts = %v, want [low high]", got.SupportedEfforts)
+	}
+}
@@ -0,0 +1,157 @@
+package codex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoStructuredOutput is returned by TurnResult.DecodeOutput when the
+// turn produced no final assistant message to decode.
+var ErrNoStructuredOutput = errors.New("codex: turn produced no final message to decode")
+
+// SchemaValidationError reports where a structured output failed to match
+// TurnOptions.OutputSchema.
+type SchemaValidationError struct {
+	// Path locates the offending value within the output, as a JSON-path-ish
+	// string such as "$.items[2].name".
+	Path string
+	// Message describes how the value failed to match the schema.
+	Message string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("codex: output schema validation failed at %s: %s", e.Path, e.Message)
+}
+
+// DecodeOutput unmarshals the turn's final assistant message into v,
+// closing the loop on TurnOptions.OutputSchema workflows. If the turn was
+// run with an OutputSchema, the decoded value is validated against it
+// first; validation is a best-effort check of "type", "required",
+// "properties", and "items" (not the full JSON Schema vocabulary), enough
+// to catch a model that ignored the schema without needing a full
+// validator dependency. DecodeOutput returns ErrNoStructuredOutput if the
+// turn produced no assistant message.
+func (r *TurnResult) DecodeOutput(v any) error {
+	msg := r.FinalMessage()
+	if msg == nil || msg.Text == "" {
+		return ErrNoStructuredOutput
+	}
+
+	if r.outputSchema != nil {
+		var value any
+		if err := json.Unmarshal([]byte(msg.Text), &value); err != nil {
+			return fmt.Errorf("codex: decode output: %w", err)
+		}
+		if err := validateAgainstSchema(r.outputSchema, value, "$"); err != nil {
+			return err
+		}
+	}
+
+	if err := json.Unmarshal([]byte(msg.Text), v); err != nil {
+		return fmt.Errorf("codex: decode output: %w", err)
+	}
+	return nil
+}
+
+// validateAgainstSchema checks value against schema at path, recursing into
+// "properties" and "items". Unrecognized schema keywords are ignored rather
+// than rejected, since this is a best-effort subset, not a full validator.
+func validateAgainstSchema(schema any, value any, path string) error {
+	schemaMap, ok := schema.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if wantType, ok := schemaMap["type"].(string); ok && !valueMatchesSchemaType(value, wantType) {
+		return &SchemaValidationError{Path: path, Message: fmt.Sprintf("want type %s, got %s", wantType, jsonTypeName(value))}
+	}
+
+	if required, ok := schemaMap["required"].([]any); ok {
+		obj, _ := value.(map[string]any)
+		for _, r := range required {
+			name, _ := r.(string)
+			if name == "" {
+				continue
+			}
+			if _, present := obj[name]; !present {
+				return &SchemaValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)}
+			}
+		}
+	}
+
+	if properties, ok := schemaMap["properties"].(map[string]any); ok {
+		obj, _ := value.(map[string]any)
+		for name, propSchema := range properties {
+			propValue, present := obj[name]
+			if !present {
+				continue
+			}
+			if err := validateAgainstSchema(propSchema, propValue, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if itemSchema, ok := schemaMap["items"]; ok {
+		for i, item := range toAnySlice(value) {
+			if err := validateAgainstSchema(itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func toAnySlice(value any) []any {
+	arr, _ := value.([]any)
+	return arr
+}
+
+func valueMatchesSchemaType(value any, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return "unknown"
+	}
+}
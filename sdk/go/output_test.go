@@ -0,0 +1,86 @@
+package codex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestDecodeOutputUnmarshalsFinalMessage(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"type": "agent_message", "text": `{"summary":"done","count":3}`},
+		},
+	}, 0)
+
+	var out struct {
+		Summary string `json:"summary"`
+		Count   int    `json:"count"`
+	}
+	if err := result.DecodeOutput(&out); err != nil {
+		t.Fatalf("DecodeOutput: %v", err)
+	}
+	if out.Summary != "done" || out.Count != 3 {
+		t.Fatalf("out = %+v, want Summary done, Count 3", out)
+	}
+}
+
+func TestDecodeOutputReturnsErrNoStructuredOutputWithoutAssistantMessage(t *testing.T) {
+	var result TurnResult
+	err := result.DecodeOutput(&struct{}{})
+	if !errors.Is(err, ErrNoStructuredOutput) {
+		t.Fatalf("DecodeOutput err = %v, want ErrNoStructuredOutput", err)
+	}
+}
+
+func TestDecodeOutputValidatesAgainstOutputSchema(t *testing.T) {
+	result := TurnResult{outputSchema: map[string]any{
+		"type":     "object",
+		"required": []any{"summary"},
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+		},
+	}}
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"type": "agent_message", "text": `{"count":3}`},
+		},
+	}, 0)
+
+	var out map[string]any
+	err := result.DecodeOutput(&out)
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("DecodeOutput err = %v, want *SchemaValidationError", err)
+	}
+}
+
+func TestDecodeOutputPassesSchemaConformingOutput(t *testing.T) {
+	result := TurnResult{outputSchema: map[string]any{
+		"type":     "object",
+		"required": []any{"summary"},
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+		},
+	}}
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"type": "agent_message", "text": `{"summary":"done"}`},
+		},
+	}, 0)
+
+	var out struct {
+		Summary string `json:"summary"`
+	}
+	if err := result.DecodeOutput(&out); err != nil {
+		t.Fatalf("DecodeOutput: %v", err)
+	}
+	if out.Summary != "done" {
+		t.Fatalf("Summary = %q, want done", out.Summary)
+	}
+}
@@ -0,0 +1,24 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pipe runs a turn on from, transforms its result into inputs via
+// transform, and runs a turn on to with those inputs, returning the
+// second turn's result. This formalizes a multi-agent hand-off (for
+// example, a planner thread feeding an executor thread) on top of
+// Thread.Run.
+func (c *Codex) Pipe(ctx context.Context, from *Thread, fromInputs []Input, to *Thread, transform func(*TurnResult) []Input) (*TurnResult, error) {
+	fromResult, err := from.Run(ctx, fromInputs...)
+	if err != nil {
+		return nil, fmt.Errorf("codex: pipe: run source thread: %w", err)
+	}
+
+	toResult, err := to.Run(ctx, transform(fromResult)...)
+	if err != nil {
+		return nil, fmt.Errorf("codex: pipe: run destination thread: %w", err)
+	}
+	return toResult, nil
+}
@@ -0,0 +1,769 @@
+package codex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestPipeTransformsSourceResultIntoDestinationInputs(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{CallTimeout: time.Second})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	from := &Thread{codex: c, id: "thread-planner"}
+	to := &Thread{codex: c, id: "thread-executor"}
+
+	transport.onWrite = func(n int, line string) {
+		switch n {
+		case 1:
+			transport.push(`{"id":1,"result":{}}`)
+			transport.push(notificationLine(t, "item/completed", map[string]any{
+				"threadId": "thread-planner",
+				"turnId":   "turn-1",
+				"item":     map[string]any{"type": "agent_message", "text": "plan: do X"},
+			}))
+			transport.push(notificationLine(t, "turn/completed", map[string]any{
+				"threadId": "thread-planner",
+				"turn":     map[string]any{"status": "completed"},
+			}))
+		case 2:
+			transport.push(`{"id":2,"result":{}}`)
+			transport.push(notificationLine(t, "item/completed", map[string]any{
+				"threadId": "thread-executor",
+				"turnId":   "turn-2",
+				"item":     map[string]any{"type": "agent_message", "text": "executed: do X"},
+			}))
+			transport.push(notificationLine(t, "turn/completed", map[string]any{
+				"threadId": "thread-executor",
+				"turn":     map[string]any{"status": "completed"},
+			}))
+		}
+	}
+
+	var seenPlan string
+	result, err := c.Pipe(context.Background(), from, nil, to, func(r *TurnResult) []Input {
+		seenPlan = r.FinalResponse
+		return []Input{TextInput(r.FinalResponse)}
+	})
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	if seenPlan != "plan: do X" {
+		t.Fatalf("transform saw %q, want %q", seenPlan, "plan: do X")
+	}
+	if result.FinalResponse != "executed: do X" {
+		t.Fatalf("FinalResponse = %q, want %q", result.FinalResponse, "executed: do X")
+	}
+}
+
+func TestRunWithOptionsInvokesOnItemSynchronously(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "command_execution", "text": "ran ls"},
+	}))
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	var seen []string
+	_, err := thread.RunWithOptions(context.Background(), TurnOptions{
+		OnItem: func(item map[string]any) {
+			itemType, _ := item["type"].(string)
+			seen = append(seen, itemType)
+		},
+	}, TextInput("go"))
+	if err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	if len(seen) != 2 || seen[0] != "command_execution" || seen[1] != "agent_message" {
+		t.Fatalf("seen = %v, want [command_execution agent_message]", seen)
+	}
+}
+
+func TestRunWithOptionsInvokesOnReasoningForReasoningItemsOnly(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "reasoning", "text": "thinking about it"},
+	}))
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	var seen []string
+	result, err := thread.RunWithOptions(context.Background(), TurnOptions{
+		OnReasoning: func(text string) {
+			seen = append(seen, text)
+		},
+	}, TextInput("go"))
+	if err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "thinking about it" {
+		t.Fatalf("seen = %v, want [thinking about it]", seen)
+	}
+	if len(result.Reasoning) != 1 || result.Reasoning[0] != "thinking about it" {
+		t.Fatalf("Reasoning = %v, want [thinking about it]", result.Reasoning)
+	}
+}
+
+func TestTurnStreamAllYieldsNotificationsUntilTurnCompleted(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	stream, err := thread.RunStreamed(context.Background(), []Input{TextInput("go")}, TurnOptions{})
+	if err != nil {
+		t.Fatalf("RunStreamed: %v", err)
+	}
+
+	var methods []string
+	for note, err := range stream.All(context.Background()) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		methods = append(methods, note.Method)
+	}
+	if len(methods) != 1 || methods[0] != "item/completed" {
+		t.Fatalf("methods = %v, want [item/completed]", methods)
+	}
+}
+
+func TestTurnStreamAllClosesOnEarlyBreak(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "command_execution", "text": "ran ls"},
+	}))
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	stream, err := thread.RunStreamed(context.Background(), []Input{TextInput("go")}, TurnOptions{})
+	if err != nil {
+		t.Fatalf("RunStreamed: %v", err)
+	}
+
+	var seen int
+	for range stream.All(context.Background()) {
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Fatalf("seen = %d, want 1", seen)
+	}
+}
+
+func TestRunPopulatesRolloutPathFromThread(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1", rolloutPath: "/home/user/.codex/sessions/thread-1.jsonl"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	result, err := thread.Run(context.Background(), TextInput("go"))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.RolloutPath != "/home/user/.codex/sessions/thread-1.jsonl" {
+		t.Fatalf("RolloutPath = %q, want the thread's rollout path", result.RolloutPath)
+	}
+}
+
+func TestRunWithOptionsSendsSeedWhenServerSupportsIt(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client, serverCapabilities: map[string]bool{"seed": true}}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	seed := 42
+	if _, err := thread.RunWithOptions(context.Background(), TurnOptions{Seed: &seed}, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["seed"] != 42.0 {
+		t.Fatalf("params[seed] = %v, want 42", params["seed"])
+	}
+}
+
+func TestRunWithOptionsDropsSeedWithoutServerSupport(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	seed := 42
+	if _, err := thread.RunWithOptions(context.Background(), TurnOptions{Seed: &seed}, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if _, ok := params["seed"]; ok {
+		t.Fatalf("params = %+v, want no seed without advertised server support", params)
+	}
+}
+
+func TestRunWithOptionsSendsTurnInstructionsWhenServerSupportsIt(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client, serverCapabilities: map[string]bool{"turnInstructions": true}}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	opts := TurnOptions{TurnInstructions: "focus on performance this time"}
+	if _, err := thread.RunWithOptions(context.Background(), opts, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["turnInstructions"] != "focus on performance this time" {
+		t.Fatalf("params[turnInstructions] = %v, want the configured instructions", params["turnInstructions"])
+	}
+}
+
+func TestRunWithOptionsDropsTurnInstructionsWithoutServerSupport(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	opts := TurnOptions{TurnInstructions: "focus on performance this time"}
+	if _, err := thread.RunWithOptions(context.Background(), opts, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if _, ok := params["turnInstructions"]; ok {
+		t.Fatalf("params = %+v, want no turnInstructions without advertised server support", params)
+	}
+}
+
+func TestRunLoggedWritesOneJSONLinePerNotification(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	var logBuf bytes.Buffer
+	result, err := thread.RunLogged(context.Background(), []Input{TextInput("go")}, TurnOptions{}, &logBuf)
+	if err != nil {
+		t.Fatalf("RunLogged: %v", err)
+	}
+	if result.FinalResponse != "done" {
+		t.Fatalf("FinalResponse = %q, want %q", result.FinalResponse, "done")
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(logBuf.String()))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 {
+		t.Fatalf("len(lines) = %d, want 2: %v", len(lines), lines)
+	}
+
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("unmarshal first line: %v", err)
+	}
+	if first["threadId"] != "thread-1" || first["turnId"] != "turn-1" || first["method"] != "item/completed" {
+		t.Fatalf("first line = %v, want threadId/turnId/method populated", first)
+	}
+}
+
+func TestRunWithOptionsSendsPriorityWhenServerSupportsIt(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client, serverCapabilities: map[string]bool{"priority": true}}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	opts := TurnOptions{Priority: PriorityBackground}
+	if _, err := thread.RunWithOptions(context.Background(), opts, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["priority"] != "background" {
+		t.Fatalf("params[priority] = %v, want \"background\"", params["priority"])
+	}
+}
+
+func TestMaxInflightLimitsConcurrentTurnDispatch(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{CallTimeout: time.Second})
+	defer client.Close()
+
+	c := &Codex{client: client, turnQueue: newTurnQueue(1)}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.onWrite = func(n int, line string) {
+		transport.push(`{"id":` + strconv.Itoa(n) + `,"result":{}}`)
+		transport.push(notificationLine(t, "turn/completed", map[string]any{
+			"threadId": "thread-1",
+			"turn":     map[string]any{"status": "completed"},
+		}))
+	}
+
+	if _, err := thread.RunWithOptions(context.Background(), TurnOptions{}, TextInput("first")); err != nil {
+		t.Fatalf("RunWithOptions (first): %v", err)
+	}
+	if _, err := thread.RunWithOptions(context.Background(), TurnOptions{}, TextInput("second")); err != nil {
+		t.Fatalf("RunWithOptions (second): %v", err)
+	}
+	if len(transport.written) != 2 {
+		t.Fatalf("written = %v, want exactly two requests dispatched in turn", transport.written)
+	}
+}
+
+func TestContinueWithLimitSendsTurnContinue(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "finished after extra tokens"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	result, err := thread.ContinueWithLimit(context.Background(), 2000, TurnOptions{})
+	if err != nil {
+		t.Fatalf("ContinueWithLimit: %v", err)
+	}
+	if result.FinalResponse != "finished after extra tokens" {
+		t.Fatalf("FinalResponse = %q, want %q", result.FinalResponse, "finished after extra tokens")
+	}
+}
+
+func TestRunWithOptionsSendsAutoCompactWhenServerSupportsIt(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client, serverCapabilities: map[string]bool{"autoCompact": true}}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	if _, err := thread.RunWithOptions(context.Background(), TurnOptions{AutoCompact: true}, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["autoCompact"] != true {
+		t.Fatalf("params[autoCompact] = %v, want true", params["autoCompact"])
+	}
+}
+
+func TestRunWithOptionsDropsAutoCompactWithoutServerSupport(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	if _, err := thread.RunWithOptions(context.Background(), TurnOptions{AutoCompact: true}, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if _, ok := params["autoCompact"]; ok {
+		t.Fatalf("params = %+v, want no autoCompact without advertised server support", params)
+	}
+}
+
+func TestRunWithOptionsSendsEffortAndSummary(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	opts := TurnOptions{Effort: EffortHigh, Summary: SummaryDetailed}
+	if _, err := thread.RunWithOptions(context.Background(), opts, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["effort"] != "high" || params["summary"] != "detailed" {
+		t.Fatalf("params = %+v, want effort=high, summary=detailed", params)
+	}
+}
+
+func TestRunWithOptionsStrictEnumsRejectsUnknownEffort(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	opts := TurnOptions{Effort: Effort("mediun"), StrictEnums: true}
+	_, err := thread.RunWithOptions(context.Background(), opts, TextInput("go"))
+	var enumErr *EnumValidationError
+	if !errors.As(err, &enumErr) || enumErr.Field != "Effort" {
+		t.Fatalf("RunWithOptions err = %v, want *EnumValidationError for Effort", err)
+	}
+	if len(transport.written) != 0 {
+		t.Fatalf("written = %v, want no request sent for a rejected enum", transport.written)
+	}
+}
+
+func TestRunWithOptionsWithoutStrictEnumsPassesUnknownEffortThrough(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	opts := TurnOptions{Effort: Effort("future-effort")}
+	if _, err := thread.RunWithOptions(context.Background(), opts, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["effort"] != "future-effort" {
+		t.Fatalf("params[effort] = %v, want future-effort to pass through", params["effort"])
+	}
+}
+
+func TestRunWithOptionsSendsApprovalPolicy(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	opts := TurnOptions{ApprovalPolicy: ApprovalPolicyOnRequest}
+	if _, err := thread.RunWithOptions(context.Background(), opts, TextInput("go")); err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["approvalPolicy"] != "on-request" {
+		t.Fatalf("params[approvalPolicy] = %v, want on-request", params["approvalPolicy"])
+	}
+}
+
+func TestRunWithOptionsStrictEnumsRejectsUnknownApprovalPolicy(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	opts := TurnOptions{ApprovalPolicy: ApprovalPolicy("sometimes"), StrictEnums: true}
+	_, err := thread.RunWithOptions(context.Background(), opts, TextInput("go"))
+	var enumErr *EnumValidationError
+	if !errors.As(err, &enumErr) || enumErr.Field != "ApprovalPolicy" {
+		t.Fatalf("RunWithOptions err = %v, want *EnumValidationError for ApprovalPolicy", err)
+	}
+	if len(transport.written) != 0 {
+		t.Fatalf("written = %v, want no request sent for a rejected enum", transport.written)
+	}
+}
+
+// routingTransport is an in-memory rpc.Transport that acts like a tiny fake
+// app-server: every turn/start request it sees is answered, asynchronously
+// and out of the caller's goroutine, with an empty result plus an
+// item/completed and turn/completed notification scoped to that request's
+// own threadId. It's used to exercise many concurrent Thread.Run calls
+// against one shared rpc.Client.
+type routingTransport struct {
+	lines chan string
+
+	mu   sync.Mutex
+	seen []string
+}
+
+func newRoutingTransport() *routingTransport {
+	return &routingTransport{lines: make(chan string, 4096)}
+}
+
+func (t *routingTransport) ReadLine() (string, error) {
+	line, ok := <-t.lines
+	if !ok {
+		return "", context.Canceled
+	}
+	return line, nil
+}
+
+func (t *routingTransport) WriteLine(line string) error {
+	t.mu.Lock()
+	t.seen = append(t.seen, line)
+	t.mu.Unlock()
+
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			ThreadID string `json:"threadId"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return err
+	}
+	if req.Method != "turn/start" {
+		return nil
+	}
+	threadID := req.Params.ThreadID
+	go func() {
+		t.lines <- fmt.Sprintf(`{"id":%s,"result":{}}`, string(req.ID))
+		t.lines <- fmt.Sprintf(`{"method":"item/completed","params":{"threadId":%q,"turnId":"turn-1","item":{"type":"agent_message","text":"reply for %s"}}}`, threadID, threadID)
+		t.lines <- fmt.Sprintf(`{"method":"turn/completed","params":{"threadId":%q,"turn":{"status":"completed"}}}`, threadID)
+	}()
+	return nil
+}
+
+func (t *routingTransport) Close() error {
+	close(t.lines)
+	return nil
+}
+
+func TestConcurrentRunsAcrossThreadsDoNotCorruptOrCrossDeliverNotifications(t *testing.T) {
+	const numThreads = 20
+
+	transport := newRoutingTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	var wg sync.WaitGroup
+	results := make([]*TurnResult, numThreads)
+	errs := make([]error, numThreads)
+	for i := 0; i < numThreads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			threadID := fmt.Sprintf("thread-%d", i)
+			thread := &Thread{codex: c, id: threadID}
+			results[i], errs[i] = thread.Run(context.Background(), TextInput("go"))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < numThreads; i++ {
+		if errs[i] != nil {
+			t.Fatalf("thread-%d Run: %v", i, errs[i])
+		}
+		want := fmt.Sprintf("reply for thread-%d", i)
+		if results[i].FinalResponse != want {
+			t.Fatalf("thread-%d FinalResponse = %q, want %q", i, results[i].FinalResponse, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package codex
+
+// Priority hints how urgently a turn should be serviced relative to others
+// sharing the same Codex.
+type Priority string
+
+const (
+	// PriorityUnspecified is the zero value: no preference is sent on the
+	// wire, and it queues as PriorityInteractive locally so an unannotated
+	// turn isn't starved behind an explicit background backlog.
+	PriorityUnspecified Priority = ""
+	// PriorityInteractive marks a turn a user is actively waiting on.
+	PriorityInteractive Priority = "interactive"
+	// PriorityBackground marks a turn that can wait behind interactive
+	// work, such as a batch job.
+	PriorityBackground Priority = "background"
+)
+
+// priorityCapability gates sending TurnOptions.Priority to the app-server;
+// see Codex.supportsCapability.
+const priorityCapability = "priority"
@@ -0,0 +1,125 @@
+// Package protocol provides typed decodings of the app-server's
+// notifications, for callers who want compile-time field access instead of
+// writing map[string]any type assertions against rpc.Notification.Params.
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// Event is implemented by every concrete type DecodeNotification can
+// return.
+type Event interface {
+	isEvent()
+}
+
+// TurnStartedEvent is the decoded payload of a turn/started notification.
+type TurnStartedEvent struct {
+	ThreadID string         `json:"threadId"`
+	Turn     map[string]any `json:"turn"`
+}
+
+func (TurnStartedEvent) isEvent() {}
+
+// ItemStartedEvent is the decoded payload of an item/started notification,
+// which the app-server sends once, before any item/updated or the item's
+// eventual item/completed.
+type ItemStartedEvent struct {
+	ThreadID string         `json:"threadId"`
+	TurnID   string         `json:"turnId"`
+	Item     map[string]any `json:"item"`
+}
+
+func (ItemStartedEvent) isEvent() {}
+
+// ItemCompletedEvent is the decoded payload of an item/completed
+// notification.
+type ItemCompletedEvent struct {
+	ThreadID string         `json:"threadId"`
+	TurnID   string         `json:"turnId"`
+	Item     map[string]any `json:"item"`
+}
+
+func (ItemCompletedEvent) isEvent() {}
+
+// TurnCompletedEvent is the decoded payload of a turn/completed
+// notification.
+type TurnCompletedEvent struct {
+	ThreadID string         `json:"threadId"`
+	Turn     map[string]any `json:"turn"`
+}
+
+func (TurnCompletedEvent) isEvent() {}
+
+// ErrorEvent is the decoded payload of an error notification raised
+// outside the context of any one turn.
+type ErrorEvent struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (ErrorEvent) isEvent() {}
+
+// UnknownEvent is returned by DecodeNotification for a method it has no
+// typed struct for, carrying the method name and raw params through rather
+// than failing outright. This keeps newer app-server notifications from
+// breaking older SDK versions.
+type UnknownEvent struct {
+	Method string
+	Params any
+}
+
+func (UnknownEvent) isEvent() {}
+
+// DecodeNotification decodes note into the concrete Event type matching
+// its method, or an UnknownEvent if no typed struct exists for it yet.
+func DecodeNotification(note rpc.Notification) (Event, error) {
+	switch note.Method {
+	case "turn/started":
+		var e TurnStartedEvent
+		if err := decodeParams(note.Params, &e); err != nil {
+			return nil, fmt.Errorf("codex/protocol: decode %s: %w", note.Method, err)
+		}
+		return e, nil
+	case "item/started":
+		var e ItemStartedEvent
+		if err := decodeParams(note.Params, &e); err != nil {
+			return nil, fmt.Errorf("codex/protocol: decode %s: %w", note.Method, err)
+		}
+		return e, nil
+	case "item/completed":
+		var e ItemCompletedEvent
+		if err := decodeParams(note.Params, &e); err != nil {
+			return nil, fmt.Errorf("codex/protocol: decode %s: %w", note.Method, err)
+		}
+		return e, nil
+	case "turn/completed":
+		var e TurnCompletedEvent
+		if err := decodeParams(note.Params, &e); err != nil {
+			return nil, fmt.Errorf("codex/protocol: decode %s: %w", note.Method, err)
+		}
+		return e, nil
+	case "error":
+		var e ErrorEvent
+		if err := decodeParams(note.Params, &e); err != nil {
+			return nil, fmt.Errorf("codex/protocol: decode %s: %w", note.Method, err)
+		}
+		return e, nil
+	default:
+		return UnknownEvent{Method: note.Method, Params: note.Params}, nil
+	}
+}
+
+// decodeParams round-trips params through JSON into dst, since
+// rpc.Notification.Params already arrived as a generic any (typically
+// map[string]any) rather than raw bytes.
+func decodeParams(params any, dst any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestDecodeNotificationItemCompleted(t *testing.T) {
+	event, err := DecodeNotification(rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"threadId": "thread-1",
+			"turnId":   "turn-1",
+			"item":     map[string]any{"type": "agent_message", "text": "done"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DecodeNotification: %v", err)
+	}
+	item, ok := event.(ItemCompletedEvent)
+	if !ok {
+		t.Fatalf("event = %T, want ItemCompletedEvent", event)
+	}
+	if item.ThreadID != "thread-1" || item.TurnID != "turn-1" {
+		t.Fatalf("item = %+v, want threadId/turnId populated", item)
+	}
+	if item.Item["text"] != "done" {
+		t.Fatalf("item.Item = %v, want text=done", item.Item)
+	}
+}
+
+func TestDecodeNotificationItemStarted(t *testing.T) {
+	event, err := DecodeNotification(rpc.Notification{
+		Method: "item/started",
+		Params: map[string]any{
+			"threadId": "thread-1",
+			"turnId":   "turn-1",
+			"item":     map[string]any{"id": "item-1", "type": "command_execution"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DecodeNotification: %v", err)
+	}
+	item, ok := event.(ItemStartedEvent)
+	if !ok {
+		t.Fatalf("event = %T, want ItemStartedEvent", event)
+	}
+	if item.ThreadID != "thread-1" || item.TurnID != "turn-1" {
+		t.Fatalf("item = %+v, want threadId/turnId populated", item)
+	}
+	if item.Item["id"] != "item-1" {
+		t.Fatalf("item.Item = %v, want id=item-1", item.Item)
+	}
+}
+
+func TestDecodeNotificationTurnCompleted(t *testing.T) {
+	event, err := DecodeNotification(rpc.Notification{
+		Method: "turn/completed",
+		Params: map[string]any{
+			"threadId": "thread-1",
+			"turn":     map[string]any{"status": "completed"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DecodeNotification: %v", err)
+	}
+	turn, ok := event.(TurnCompletedEvent)
+	if !ok {
+		t.Fatalf("event = %T, want TurnCompletedEvent", event)
+	}
+	if turn.Turn["status"] != "completed" {
+		t.Fatalf("turn.Turn = %v, want status=completed", turn.Turn)
+	}
+}
+
+func TestDecodeNotificationUnknownMethod(t *testing.T) {
+	event, err := DecodeNotification(rpc.Notification{
+		Method: "turn/diffUpdated",
+		Params: map[string]any{"diff": "some diff"},
+	})
+	if err != nil {
+		t.Fatalf("DecodeNotification: %v", err)
+	}
+	unknown, ok := event.(UnknownEvent)
+	if !ok {
+		t.Fatalf("event = %T, want UnknownEvent", event)
+	}
+	if unknown.Method != "turn/diffUpdated" {
+		t.Fatalf("unknown.Method = %q, want turn/diffUpdated", unknown.Method)
+	}
+}
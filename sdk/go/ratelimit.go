@@ -0,0 +1,79 @@
+package codex
+
+import "github.com/openai/codex/sdk/go/rpc"
+
+// RateLimitWindow reports usage against one rolling rate-limit window (for
+// example, a 5-hour or weekly window), as last reported by the
+// app-server's account/rateLimits/updated notification.
+type RateLimitWindow struct {
+	// UsedPercent is how much of this window's quota has been consumed,
+	// 0-100.
+	UsedPercent int
+	// WindowDurationMins is the window's length in minutes, or nil if the
+	// app-server didn't report one.
+	WindowDurationMins *int64
+	// ResetsAt is the Unix timestamp, in seconds, the window resets at, or
+	// nil if unknown.
+	ResetsAt *int64
+}
+
+// RateLimitInfo is the app-server's most recently reported rate-limit
+// state, decoded from an account/rateLimits/updated notification observed
+// during a turn. It's attached to TurnResult.RateLimits, and to
+// TurnError.RateLimits for a turn that failed, so a caller can back off
+// intelligently instead of blindly retrying.
+//
+// The app-server reports rate limits as rolling usage-percentage windows
+// scoped to the whole account, not as a per-request Limit/Remaining/
+// RetryAfter header the way some HTTP APIs do; there's no request-scoped
+// token-bucket count on the wire to surface here, so RateLimitInfo sticks
+// to the shape the app-server actually sends.
+type RateLimitInfo struct {
+	// Primary is the shorter, more restrictive window (for example, a
+	// 5-hour window), or nil if the app-server didn't report one.
+	Primary *RateLimitWindow
+	// Secondary is the longer window (for example, a weekly window), or
+	// nil if the app-server didn't report one.
+	Secondary *RateLimitWindow
+}
+
+// decodeRateLimitInfo decodes note if it's an account/rateLimits/updated
+// notification, returning ok=false for any other method or a malformed
+// payload.
+func decodeRateLimitInfo(note rpc.Notification) (info *RateLimitInfo, ok bool) {
+	if note.Method != "account/rateLimits/updated" {
+		return nil, false
+	}
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	snapshot, ok := params["rateLimits"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return &RateLimitInfo{
+		Primary:   decodeRateLimitWindow(snapshot["primary"]),
+		Secondary: decodeRateLimitWindow(snapshot["secondary"]),
+	}, true
+}
+
+func decodeRateLimitWindow(v any) *RateLimitWindow {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	window := &RateLimitWindow{}
+	if percent, ok := m["usedPercent"].(float64); ok {
+		window.UsedPercent = int(percent)
+	}
+	if mins, ok := m["windowDurationMins"].(float64); ok {
+		rounded := int64(mins)
+		window.WindowDurationMins = &rounded
+	}
+	if resetsAt, ok := m["resetsAt"].(float64); ok {
+		rounded := int64(resetsAt)
+		window.ResetsAt = &rounded
+	}
+	return window
+}
@@ -0,0 +1,51 @@
+package codex
+
+import (
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestUpdateTurnResultDecodesRateLimits(t *testing.T) {
+	var result TurnResult
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "account/rateLimits/updated",
+		Params: map[string]any{
+			"rateLimits": map[string]any{
+				"primary": map[string]any{
+					"usedPercent":        42.0,
+					"windowDurationMins": 300.0,
+					"resetsAt":           1700000000.0,
+				},
+				"secondary": map[string]any{
+					"usedPercent": 10.0,
+				},
+			},
+		},
+	}, 0)
+
+	if result.RateLimits == nil {
+		t.Fatal("RateLimits is nil, want populated")
+	}
+	if result.RateLimits.Primary == nil || result.RateLimits.Primary.UsedPercent != 42 {
+		t.Fatalf("Primary = %+v, want UsedPercent 42", result.RateLimits.Primary)
+	}
+	if got := *result.RateLimits.Primary.WindowDurationMins; got != 300 {
+		t.Fatalf("Primary.WindowDurationMins = %d, want 300", got)
+	}
+	if got := *result.RateLimits.Primary.ResetsAt; got != 1700000000 {
+		t.Fatalf("Primary.ResetsAt = %d, want 1700000000", got)
+	}
+	if result.RateLimits.Secondary == nil || result.RateLimits.Secondary.UsedPercent != 10 {
+		t.Fatalf("Secondary = %+v, want UsedPercent 10", result.RateLimits.Secondary)
+	}
+}
+
+func TestUpdateTurnResultIgnoresUnrelatedNotificationsForRateLimits(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{Method: "turn/heartbeat"}, 0)
+	if result.RateLimits != nil {
+		t.Fatalf("RateLimits = %+v, want nil", result.RateLimits)
+	}
+}
@@ -0,0 +1,106 @@
+package codex
+
+import (
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// ReconnectPolicy configures Options.Reconnect.
+type ReconnectPolicy struct {
+	// MaxAttempts bounds how many times Codex respawns the app-server after
+	// the transport drops before giving up. Zero (the default
+	// ReconnectPolicy) disables automatic reconnect entirely, matching
+	// prior behavior: a dropped transport leaves the Codex unusable.
+	MaxAttempts int
+	// Backoff is the delay between reconnect attempts.
+	Backoff time.Duration
+}
+
+// watchForDisconnect blocks until the current client's read loop exits,
+// then, if that wasn't caused by an explicit Close and reconnect is
+// enabled, tries to respawn the app-server and resume every thread this
+// Codex has opened so far.
+func (c *Codex) watchForDisconnect() {
+	<-c.client.Done()
+
+	c.mu.Lock()
+	closedByCaller := c.closed
+	c.mu.Unlock()
+	if closedByCaller || c.reconnect.MaxAttempts == 0 {
+		return
+	}
+
+	for attempt := 1; attempt <= c.reconnect.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(c.reconnect.Backoff)
+		}
+		if err := c.respawn(); err != nil {
+			continue
+		}
+		go c.watchForDisconnect()
+		return
+	}
+}
+
+// respawn starts a fresh app-server subprocess, re-runs initialize, and
+// resumes every thread this Codex has opened, swapping them in for the
+// dead client and transport on success.
+func (c *Codex) respawn() error {
+	transport, err := rpc.SpawnStdio(c.spawnPath, c.spawnOptions)
+	if err != nil {
+		return err
+	}
+	client := rpc.NewClient(transport, rpc.ClientOptions{RequestHandler: c.handleServerRequest, Tracer: c.tracer})
+
+	var initResp struct {
+		UserAgent      string   `json:"userAgent"`
+		CodexHome      string   `json:"codexHome"`
+		PlatformFamily string   `json:"platformFamily"`
+		PlatformOS     string   `json:"platformOs"`
+		Capabilities   []string `json:"capabilities"`
+	}
+	if err := client.Call("initialize", map[string]any{}, &initResp); err != nil {
+		_ = client.Close()
+		return err
+	}
+	capabilities := map[string]bool{}
+	for _, capability := range initResp.Capabilities {
+		capabilities[capability] = true
+	}
+
+	c.mu.Lock()
+	c.client = client
+	c.transport = transport
+	c.serverCapabilities = capabilities
+	c.serverInfo = ServerInfo{
+		UserAgent:      initResp.UserAgent,
+		CodexHome:      initResp.CodexHome,
+		PlatformFamily: initResp.PlatformFamily,
+		PlatformOS:     initResp.PlatformOS,
+		Capabilities:   initResp.Capabilities,
+	}
+	threadIDs := make([]string, 0, len(c.openThreadIDs))
+	for id := range c.openThreadIDs {
+		threadIDs = append(threadIDs, id)
+	}
+	c.mu.Unlock()
+
+	for _, id := range threadIDs {
+		var resp threadWireResponse
+		_ = client.Call("thread/resume", map[string]any{"threadId": id}, &resp)
+	}
+	return nil
+}
+
+// trackOpenThread records id as a thread to resume after a reconnect. It's
+// a no-op when reconnect isn't configured, so Codexes that never enable it
+// don't pay for tracking threads they'll never need to resume.
+func (c *Codex) trackOpenThread(id string) {
+	if c.reconnect.MaxAttempts == 0 || id == "" {
+		return
+	}
+	c.mu.Lock()
+	c.openThreadIDs[id] = struct{}{}
+	c.mu.Unlock()
+}
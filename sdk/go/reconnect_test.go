@@ -0,0 +1,28 @@
+package codex
+
+import "testing"
+
+func TestTrackOpenThreadNoopWithoutReconnectPolicy(t *testing.T) {
+	c := &Codex{openThreadIDs: map[string]struct{}{}}
+	c.trackOpenThread("thread-1")
+
+	if len(c.openThreadIDs) != 0 {
+		t.Fatalf("openThreadIDs = %v, want empty without Options.Reconnect", c.openThreadIDs)
+	}
+}
+
+func TestTrackOpenThreadRecordsIDWhenReconnectEnabled(t *testing.T) {
+	c := &Codex{
+		openThreadIDs: map[string]struct{}{},
+		reconnect:     ReconnectPolicy{MaxAttempts: 3},
+	}
+	c.trackOpenThread("thread-1")
+	c.trackOpenThread("thread-2")
+
+	if _, ok := c.openThreadIDs["thread-1"]; !ok {
+		t.Fatalf("openThreadIDs = %v, want thread-1 tracked", c.openThreadIDs)
+	}
+	if _, ok := c.openThreadIDs["thread-2"]; !ok {
+		t.Fatalf("openThreadIDs = %v, want thread-2 tracked", c.openThreadIDs)
+	}
+}
@@ -0,0 +1,90 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RepoContextOptions configures how Thread.RunWithRepoContext gathers
+// repository context and attaches it to a turn.
+type RepoContextOptions struct {
+	// IncludeBranch, when true, attaches the current branch name.
+	IncludeBranch bool
+	// IncludeDiff, when true, attaches the working tree's uncommitted
+	// diff.
+	IncludeDiff bool
+	// MaxDiffBytes caps the size of the attached diff, truncating anything
+	// beyond it. Zero means no cap.
+	MaxDiffBytes int
+	// Files lists additional repoRoot-relative paths to attach verbatim,
+	// for example key config files the agent should always see.
+	Files []string
+}
+
+// RunWithRepoContext is Run, but first gathers repoRoot's git metadata
+// (branch, working-tree diff) and any configured Files per opts, and
+// prepends them to prompt as a preamble text Input. This encapsulates the
+// repetitive, error-prone setup of attaching repo context by hand.
+func (t *Thread) RunWithRepoContext(ctx context.Context, prompt string, repoRoot string, opts RepoContextOptions) (*TurnResult, error) {
+	preamble, err := buildRepoContextPreamble(ctx, repoRoot, opts)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []Input
+	if preamble != "" {
+		inputs = append(inputs, TextInput(preamble))
+	}
+	inputs = append(inputs, TextInput(prompt))
+	return t.Run(ctx, inputs...)
+}
+
+// buildRepoContextPreamble assembles the sections opts asks for, in a
+// fixed order (branch, diff, files), joined with blank lines.
+func buildRepoContextPreamble(ctx context.Context, repoRoot string, opts RepoContextOptions) (string, error) {
+	var sections []string
+
+	if opts.IncludeBranch {
+		branch, err := runGit(ctx, repoRoot, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return "", fmt.Errorf("codex: resolve git branch: %w", err)
+		}
+		sections = append(sections, "Branch: "+strings.TrimSpace(branch))
+	}
+
+	if opts.IncludeDiff {
+		diff, err := runGit(ctx, repoRoot, "diff")
+		if err != nil {
+			return "", fmt.Errorf("codex: gather git diff: %w", err)
+		}
+		if opts.MaxDiffBytes > 0 && len(diff) > opts.MaxDiffBytes {
+			diff = diff[:opts.MaxDiffBytes] + "\n... (truncated)"
+		}
+		if strings.TrimSpace(diff) != "" {
+			sections = append(sections, "Diff:\n"+diff)
+		}
+	}
+
+	for _, path := range opts.Files {
+		content, err := os.ReadFile(filepath.Join(repoRoot, path))
+		if err != nil {
+			return "", fmt.Errorf("codex: read repo context file %q: %w", path, err)
+		}
+		sections = append(sections, fmt.Sprintf("File %s:\n%s", path, content))
+	}
+
+	return strings.Join(sections, "\n\n"), nil
+}
+
+func runGit(ctx context.Context, repoRoot string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("codex: git %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
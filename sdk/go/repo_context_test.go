@@ -0,0 +1,99 @@
+package codex
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	run("add", "README.md")
+	run("commit", "-q", "-m", "initial")
+	return dir
+}
+
+func TestBuildRepoContextPreambleIncludesBranch(t *testing.T) {
+	dir := initTestRepo(t)
+
+	preamble, err := buildRepoContextPreamble(context.Background(), dir, RepoContextOptions{IncludeBranch: true})
+	if err != nil {
+		t.Fatalf("buildRepoContextPreamble: %v", err)
+	}
+	if !strings.Contains(preamble, "Branch: main") {
+		t.Fatalf("preamble = %q, want it to mention the branch", preamble)
+	}
+}
+
+func TestBuildRepoContextPreambleIncludesDiff(t *testing.T) {
+	dir := initTestRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nworld\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	preamble, err := buildRepoContextPreamble(context.Background(), dir, RepoContextOptions{IncludeDiff: true})
+	if err != nil {
+		t.Fatalf("buildRepoContextPreamble: %v", err)
+	}
+	if !strings.Contains(preamble, "+world") {
+		t.Fatalf("preamble = %q, want it to contain the diff", preamble)
+	}
+}
+
+func TestBuildRepoContextPreambleTruncatesLongDiff(t *testing.T) {
+	dir := initTestRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(strings.Repeat("x\n", 1000)), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	preamble, err := buildRepoContextPreamble(context.Background(), dir, RepoContextOptions{IncludeDiff: true, MaxDiffBytes: 50})
+	if err != nil {
+		t.Fatalf("buildRepoContextPreamble: %v", err)
+	}
+	if !strings.Contains(preamble, "truncated") {
+		t.Fatalf("preamble = %q, want a truncation marker", preamble)
+	}
+}
+
+func TestBuildRepoContextPreambleAttachesFiles(t *testing.T) {
+	dir := initTestRepo(t)
+
+	preamble, err := buildRepoContextPreamble(context.Background(), dir, RepoContextOptions{Files: []string{"README.md"}})
+	if err != nil {
+		t.Fatalf("buildRepoContextPreamble: %v", err)
+	}
+	if !strings.Contains(preamble, "File README.md:") || !strings.Contains(preamble, "hello") {
+		t.Fatalf("preamble = %q, want it to include README.md's contents", preamble)
+	}
+}
+
+func TestBuildRepoContextPreambleEmptyWithNoOptions(t *testing.T) {
+	dir := initTestRepo(t)
+
+	preamble, err := buildRepoContextPreamble(context.Background(), dir, RepoContextOptions{})
+	if err != nil {
+		t.Fatalf("buildRepoContextPreamble: %v", err)
+	}
+	if preamble != "" {
+		t.Fatalf("preamble = %q, want empty", preamble)
+	}
+}
@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BatchRequest is one entry in a Client.Batch call: either an RPC call
+// expecting a response, or a fire-and-forget notification.
+type BatchRequest struct {
+	// Method is the JSON-RPC method to invoke.
+	Method string
+	// Params are marshaled as this entry's params.
+	Params any
+	// Notify, when true, sends this entry without a request id, so the
+	// server treats it as a notification and Batch doesn't wait for (or
+	// expect) a response to it.
+	Notify bool
+}
+
+// BatchResponse is one entry in Client.Batch's result, aligned by index
+// with the BatchRequest it answers. A Notify entry's BatchResponse is
+// always the zero value, since notifications get no response.
+type BatchResponse struct {
+	// Result is the entry's raw JSON-RPC result, or nil if Err is set or
+	// the entry was a notification.
+	Result json.RawMessage
+	// Err is the entry's failure, either an *RPCError reported by the
+	// server or ctx.Err() if ctx was done before a response arrived. Nil
+	// on success.
+	Err error
+}
+
+// batchPendingEntry tracks one in-flight call entry of a Batch request:
+// which response slot it belongs to and the channel its response arrives
+// on.
+type batchPendingEntry struct {
+	index int
+	id    string
+	ch    chan wireMessage
+}
+
+// Batch sends several independent calls and notifications as a single
+// JSON-RPC batch request: one line on the wire carrying a JSON array of
+// request objects, useful for configuring several settings at once
+// without a network round trip per call. It blocks until every call entry
+// in requests has a response, or ctx is done first. One entry failing
+// doesn't affect the others: each BatchResponse reports its own entry's
+// outcome independently, so a partial failure never hides the entries
+// that succeeded. ClientOptions.CallTimeout, if set, bounds the whole
+// batch rather than each entry individually; once it elapses, every
+// entry still outstanding fails with ctx.Err(), the same as if ctx itself
+// had been canceled.
+func (c *Client) Batch(ctx context.Context, requests []BatchRequest) ([]BatchResponse, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("codex/rpc: rate limit wait for batch: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errors.New("codex/rpc: client is closed")
+	}
+	if c.closing {
+		c.mu.Unlock()
+		return nil, ErrClientClosing
+	}
+
+	wire := make([]wireMessage, len(requests))
+	var pendingEntries []batchPendingEntry
+	for i, req := range requests {
+		paramsBytes, err := c.codec.Marshal(req.Params)
+		if err != nil {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("codex/rpc: marshal params for batch entry %d (%s): %w", i, req.Method, err)
+		}
+		wire[i] = wireMessage{Method: req.Method, Params: paramsBytes}
+		if req.Notify {
+			continue
+		}
+		idBytes, err := c.codec.Marshal(c.nextID())
+		if err != nil {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("codex/rpc: marshal id for batch entry %d (%s): %w", i, req.Method, err)
+		}
+		wire[i].ID = idBytes
+		ch := make(chan wireMessage, 1)
+		c.pending[string(idBytes)] = ch
+		c.pendingWG.Add(1)
+		pendingEntries = append(pendingEntries, batchPendingEntry{index: i, id: string(idBytes), ch: ch})
+	}
+	c.mu.Unlock()
+
+	line, err := c.codec.Marshal(wire)
+	if err != nil {
+		c.cancelBatchEntries(pendingEntries)
+		return nil, fmt.Errorf("codex/rpc: marshal batch request: %w", err)
+	}
+	if err := c.writeLine(string(line)); err != nil {
+		c.cancelBatchEntries(pendingEntries)
+		return nil, fmt.Errorf("codex/rpc: write batch request: %w", err)
+	}
+
+	responses := make([]BatchResponse, len(requests))
+	for _, entry := range pendingEntries {
+		req := requests[entry.index]
+		select {
+		case resp := <-entry.ch:
+			if resp.Error != nil {
+				responses[entry.index].Err = &RPCError{Method: req.Method, Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+			} else {
+				responses[entry.index].Result = resp.Result
+			}
+		case <-ctx.Done():
+			c.mu.Lock()
+			delete(c.pending, entry.id)
+			c.mu.Unlock()
+			responses[entry.index].Err = ctx.Err()
+		}
+		c.pendingWG.Done()
+	}
+
+	return responses, nil
+}
+
+// cancelBatchEntries unregisters every still-pending entry after the
+// batch request itself failed to send, so they don't leak in c.pending
+// forever waiting on a response that will never arrive.
+func (c *Client) cancelBatchEntries(entries []batchPendingEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range entries {
+		delete(c.pending, entry.id)
+		c.pendingWG.Done()
+	}
+}
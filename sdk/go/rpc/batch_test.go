@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchSendsSingleArrayLineAndCorrelatesResponses(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	done := make(chan []BatchResponse, 1)
+	go func() {
+		resp, err := client.Batch(context.Background(), []BatchRequest{
+			{Method: "a", Params: map[string]any{"x": 1}},
+			{Method: "b", Notify: true, Params: map[string]any{"y": 2}},
+			{Method: "c", Params: map[string]any{"z": 3}},
+		})
+		if err != nil {
+			t.Errorf("Batch: %v", err)
+		}
+		done <- resp
+	}()
+
+	line := transport.waitForWrite(t)
+	var wire []wireMessage
+	if err := json.Unmarshal([]byte(line), &wire); err != nil {
+		t.Fatalf("unmarshal batch line as array: %v", err)
+	}
+	if len(wire) != 3 {
+		t.Fatalf("len(wire) = %d, want 3", len(wire))
+	}
+	if wire[1].ID != nil {
+		t.Fatalf("wire[1].ID = %s, want a notify entry with no id", wire[1].ID)
+	}
+	if wire[0].ID == nil || wire[2].ID == nil {
+		t.Fatal("wire[0] and wire[2] should carry request ids")
+	}
+
+	batchResp, err := json.Marshal([]wireMessage{
+		{ID: wire[0].ID, Result: json.RawMessage(`{"ok":true}`)},
+		{ID: wire[2].ID, Error: &wireError{Code: -32000, Message: "boom"}},
+	})
+	if err != nil {
+		t.Fatalf("marshal batch response: %v", err)
+	}
+	transport.pushFromServer(string(batchResp))
+
+	select {
+	case resp := <-done:
+		if len(resp) != 3 {
+			t.Fatalf("len(resp) = %d, want 3", len(resp))
+		}
+		if resp[0].Err != nil || string(resp[0].Result) != `{"ok":true}` {
+			t.Fatalf("resp[0] = %+v, want ok result", resp[0])
+		}
+		if resp[1].Err != nil || resp[1].Result != nil {
+			t.Fatalf("resp[1] = %+v, want the zero value (it was a notify entry)", resp[1])
+		}
+		var rpcErr *RPCError
+		if !errors.As(resp[2].Err, &rpcErr) || rpcErr.Message != "boom" {
+			t.Fatalf("resp[2].Err = %v, want an *RPCError with message boom", resp[2].Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Batch to return")
+	}
+}
+
+func TestBatchReturnsCtxErrForUnansweredEntry(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	resp, err := client.Batch(ctx, []BatchRequest{{Method: "a", Params: map[string]any{}}})
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(resp) != 1 || resp[0].Err == nil {
+		t.Fatalf("resp = %+v, want a single entry with an error", resp)
+	}
+}
+
+func TestBatchWithNoRequestsReturnsNil(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	resp, err := client.Batch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("resp = %v, want nil", resp)
+	}
+}
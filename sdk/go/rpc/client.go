@@ -0,0 +1,698 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestID identifies a JSON-RPC request. The wire format allows either a
+// string or an integer; NewIntRequestID is the default generator.
+type RequestID any
+
+// NewIntRequestID returns a generator that produces monotonically
+// increasing integer request ids, starting at 1.
+func NewIntRequestID() func() RequestID {
+	var next int64
+	return func() RequestID {
+		return atomic.AddInt64(&next, 1)
+	}
+}
+
+// Notification is a server-initiated JSON-RPC notification (no id, no
+// response expected).
+type Notification struct {
+	Method string
+	Params any
+}
+
+type wireMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *wireError      `json:"error,omitempty"`
+}
+
+type wireError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Codec marshals and unmarshals the JSON-RPC envelope and payloads a Client
+// sends and receives. The default, used when ClientOptions.Codec is nil,
+// wraps encoding/json. Implementations must produce and accept the same
+// JSON wire format encoding/json does (the app-server doesn't know or care
+// which codec a Go client used), but may differ in performance or in
+// incidental behavior such as preserving struct field order, which matters
+// for a codec used to produce deterministic record/replay transcripts.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// jsonCodec is the default Codec, implemented directly in terms of
+// encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// RequestIDFunc generates the id for each outgoing request. Defaults to
+	// NewIntRequestID().
+	RequestIDFunc func() RequestID
+
+	// Codec marshals and unmarshals every JSON-RPC frame the Client sends or
+	// reads. Defaults to encoding/json. Plugging in a faster drop-in (such
+	// as a SIMD-accelerated encoder) is mainly useful to a high-throughput
+	// server juggling many concurrent turns; a codec that preserves struct
+	// field order is useful for deterministic record/replay transcripts.
+	// RecordTransport and FileRecordTransport capture whatever line the
+	// Client already produced, so they pick up the configured Codec's
+	// output automatically and need no Codec of their own.
+	Codec Codec
+
+	// RequestHandler answers server-initiated requests (method calls the
+	// app-server makes of the client, such as approval prompts). When nil,
+	// or when it returns ErrMethodNotFound, the client replies with a
+	// JSON-RPC "method not found" error.
+	RequestHandler func(method string, params any) (any, error)
+
+	// CallTimeout, when greater than zero, bounds how long Call waits for a
+	// response before giving up and returning a *TimeoutError. Zero means
+	// Call blocks until a response arrives, with no bound of its own; this
+	// is the default, matching prior behavior.
+	CallTimeout time.Duration
+
+	// Tracer, when set, starts a span around every Call named after the
+	// JSON-RPC method, with attributes for the request id and the
+	// marshaled params size. Defaults to NoopTracer(), so tracing is
+	// zero-cost until a caller supplies one.
+	Tracer Tracer
+
+	// DebugFrames, when true, logs every raw JSON-RPC frame sent and
+	// received at slog.LevelDebug via Logger, with embedded base64
+	// payloads (such as inline images) redacted so they don't flood logs
+	// or leak sensitive content. Off by default; for live byte-level
+	// inspection without a Client at all, see TapTransport instead.
+	DebugFrames bool
+	// Logger receives debug frame logs when DebugFrames is set. Defaults
+	// to slog.Default().
+	Logger *slog.Logger
+
+	// RateLimiter, when set, is consulted by Call, CallWithMeta, and
+	// Notify before each send, blocking until it admits a token. This
+	// smooths client-side request bursts instead of relying on the
+	// app-server to reject them and the caller to retry; a
+	// *rate.Limiter from golang.org/x/time/rate satisfies this
+	// interface as-is. It composes with a Thread.RunWithOptions
+	// TurnOptions.RetryPolicy rather than replacing it: RetryPolicy
+	// governs whether and when a failed turn is restarted at all, while
+	// RateLimiter governs how quickly every Call a restarted (or any
+	// other) turn makes is allowed onto the wire. Nil (the default)
+	// means no limiting.
+	RateLimiter RateLimiter
+}
+
+// RateLimiter restricts how fast ClientOptions.RateLimiter-configured Call,
+// CallWithMeta, and Notify calls may send requests to the server. Wait
+// blocks until a token is available or ctx is done, matching
+// golang.org/x/time/rate.Limiter's Wait method.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TimeoutError is returned by Client.Call when its response doesn't arrive
+// before ClientOptions.CallTimeout elapses.
+type TimeoutError struct {
+	// Method is the RPC method that timed out.
+	Method string
+	// Elapsed is how long Call waited before giving up.
+	Elapsed time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("codex/rpc: %s timed out after %s", e.Method, e.Elapsed)
+}
+
+// RPCError is returned by Call when the server's response carries a
+// JSON-RPC error object, preserving its code, message, and any extra data
+// so callers can errors.As into it instead of matching on the formatted
+// error text.
+type RPCError struct {
+	// Method is the RPC method that failed.
+	Method string
+	// Code is the JSON-RPC error code the server reported.
+	Code int
+	// Message is the JSON-RPC error message the server reported.
+	Message string
+	// Data is the JSON-RPC error object's optional "data" member, verbatim.
+	Data json.RawMessage
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("codex/rpc: %s failed: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// ErrMethodNotFound can be returned by a ClientOptions.RequestHandler to
+// signal that it doesn't know how to answer a particular method; the
+// client reports this to the server as a JSON-RPC "method not found" error
+// rather than treating it as a handler failure.
+var ErrMethodNotFound = errors.New("codex/rpc: method not found")
+
+const methodNotFoundCode = -32601
+
+// ErrClientClosing is returned by Call once CloseGracefully has been
+// called, rejecting new calls while any already in flight are still given
+// a chance to receive their response.
+var ErrClientClosing = errors.New("codex/rpc: client is closing")
+
+// Client is a minimal JSON-RPC client over a Transport: it correlates
+// request/response pairs and fans server notifications out to subscribers.
+//
+// Client is safe for concurrent use. Call/CallWithMeta from any number of
+// goroutines correlate responses correctly, since each call gets its own
+// request id and its own response channel in c.pending, guarded by c.mu.
+// Concurrent SubscribeNotifications* calls are likewise safe: a single
+// read-loop goroutine dispatches each incoming notification to every
+// subscription's own channel in turn, so two subscribers (for example, two
+// Thread.Run calls on distinct thread ids sharing one Client) never see
+// each other's notifications or a corrupted/interleaved Notification value.
+// The one caveat is throughput, not correctness: dispatch to all
+// subscribers happens on that same read-loop goroutine, so a subscriber
+// that blocks on OverflowBlock (the default) delays delivery to every
+// other subscriber until it's read, or until it's removed via
+// unsubscribe.
+type Client struct {
+	transport      Transport
+	nextID         func() RequestID
+	requestHandler func(method string, params any) (any, error)
+	callTimeout    time.Duration
+	tracer         Tracer
+	debugLog       *debugFrameLogger
+	codec          Codec
+	rateLimiter    RateLimiter
+
+	mu        sync.Mutex
+	pending   map[string]chan wireMessage
+	pendingWG sync.WaitGroup
+	subs      []subscription
+	closed    bool
+	closing   bool
+
+	readLoopDone chan struct{}
+}
+
+// NewClient starts a Client reading from transport in the background.
+func NewClient(transport Transport, opts ClientOptions) *Client {
+	idFunc := opts.RequestIDFunc
+	if idFunc == nil {
+		idFunc = NewIntRequestID()
+	}
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = NoopTracer()
+	}
+	var debugLog *debugFrameLogger
+	if opts.DebugFrames {
+		logger := opts.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		debugLog = &debugFrameLogger{logger: logger}
+	}
+	codec := opts.Codec
+	if codec == nil {
+		codec = jsonCodec{}
+	}
+	c := &Client{
+		transport:      transport,
+		nextID:         idFunc,
+		requestHandler: opts.RequestHandler,
+		callTimeout:    opts.CallTimeout,
+		tracer:         tracer,
+		debugLog:       debugLog,
+		codec:          codec,
+		rateLimiter:    opts.RateLimiter,
+		pending:        make(map[string]chan wireMessage),
+		readLoopDone:   make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// writeLine writes line to the transport, logging it first if
+// ClientOptions.DebugFrames is set.
+func (c *Client) writeLine(line string) error {
+	c.debugLog.logSent(line)
+	return c.transport.WriteLine(line)
+}
+
+func (c *Client) readLoop() {
+	defer close(c.readLoopDone)
+	for {
+		line, err := c.transport.ReadLine()
+		if err != nil {
+			return
+		}
+		c.debugLog.logReceived(line)
+		if isBatchLine(line) {
+			var msgs []wireMessage
+			if err := c.codec.Unmarshal([]byte(line), &msgs); err != nil {
+				continue
+			}
+			for _, msg := range msgs {
+				c.dispatchMessage(msg)
+			}
+			continue
+		}
+		var msg wireMessage
+		if err := c.codec.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+		c.dispatchMessage(msg)
+	}
+}
+
+// isBatchLine reports whether line is a JSON-RPC batch: a top-level JSON
+// array rather than a single request/response/notification object, as
+// Client.Batch sends and expects to receive back.
+func isBatchLine(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t\r\n")
+	return strings.HasPrefix(trimmed, "[")
+}
+
+// dispatchMessage routes one decoded wireMessage to the right handler,
+// whether it arrived on its own line or as one entry of a batch array.
+func (c *Client) dispatchMessage(msg wireMessage) {
+	if msg.Method != "" && len(msg.ID) == 0 {
+		c.dispatchNotification(msg)
+		return
+	}
+	if msg.Method != "" && len(msg.ID) > 0 {
+		c.dispatchServerRequest(msg)
+		return
+	}
+	c.dispatchResponse(msg)
+}
+
+// dispatchServerRequest answers a request the server sent to the client
+// (as opposed to a response to one of our own Call invocations). The
+// RequestHandler runs on its own goroutine so a slow or interactive
+// handler (for example, one waiting on a human approval) never delays the
+// read loop from delivering notifications or responses for anything else
+// in flight.
+func (c *Client) dispatchServerRequest(msg wireMessage) {
+	go c.answerServerRequest(msg)
+}
+
+func (c *Client) answerServerRequest(msg wireMessage) {
+	var params any
+	if len(msg.Params) > 0 {
+		_ = c.codec.Unmarshal(msg.Params, &params)
+	}
+
+	if c.requestHandler == nil {
+		c.replyMethodNotFound(msg)
+		return
+	}
+
+	result, err := c.requestHandler(msg.Method, params)
+	if errors.Is(err, ErrMethodNotFound) {
+		c.replyMethodNotFound(msg)
+		return
+	}
+	if err != nil {
+		c.replyError(msg, -32000, err.Error())
+		return
+	}
+	c.replyResult(msg, result)
+}
+
+func (c *Client) replyMethodNotFound(msg wireMessage) {
+	c.replyError(msg, methodNotFoundCode, fmt.Sprintf("method not found: %s", msg.Method))
+}
+
+func (c *Client) replyError(msg wireMessage, code int, message string) {
+	resp := wireMessage{ID: msg.ID, Error: &wireError{Code: code, Message: message}}
+	line, err := c.codec.Marshal(resp)
+	if err != nil {
+		return
+	}
+	_ = c.writeLine(string(line))
+}
+
+func (c *Client) replyResult(msg wireMessage, result any) {
+	resultBytes, err := c.codec.Marshal(result)
+	if err != nil {
+		c.replyError(msg, -32000, fmt.Sprintf("marshal result for %s: %v", msg.Method, err))
+		return
+	}
+	resp := wireMessage{ID: msg.ID, Result: resultBytes}
+	line, err := c.codec.Marshal(resp)
+	if err != nil {
+		return
+	}
+	_ = c.writeLine(string(line))
+}
+
+func (c *Client) dispatchNotification(msg wireMessage) {
+	var params any
+	if len(msg.Params) > 0 {
+		_ = c.codec.Unmarshal(msg.Params, &params)
+	}
+	c.PublishNotification(Notification{Method: msg.Method, Params: params})
+}
+
+// PublishNotification delivers note to every current subscriber, exactly as
+// if it had arrived from the app-server over the wire. It's for notifications
+// the SDK itself raises on the client's behalf (such as mirroring a
+// server-initiated request as an observable notification) rather than ones
+// that actually came from the app-server.
+func (c *Client) PublishNotification(note Notification) {
+	c.mu.Lock()
+	subs := append([]subscription(nil), c.subs...)
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.filter != nil && !sub.filter(note) {
+			continue
+		}
+		deliverToSubscriber(sub, note)
+	}
+}
+
+// deliverToSubscriber sends note to sub's channel according to its
+// overflow policy: OverflowBlock sends unconditionally, potentially
+// blocking dispatchNotification; OverflowDropOldest sends without
+// blocking, first discarding the oldest buffered notification if the
+// channel is full to make room for the new one.
+func deliverToSubscriber(sub subscription, note Notification) {
+	if sub.overflow != OverflowDropOldest {
+		sub.ch <- note
+		return
+	}
+	select {
+	case sub.ch <- note:
+	default:
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- note:
+		default:
+		}
+	}
+}
+
+func (c *Client) dispatchResponse(msg wireMessage) {
+	key := string(msg.ID)
+	c.mu.Lock()
+	ch, ok := c.pending[key]
+	if ok {
+		delete(c.pending, key)
+	}
+	c.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// Call sends method with params and decodes the result into result (which
+// should be a pointer), blocking until a response arrives. The span it
+// starts (see ClientOptions.Tracer) is rooted in context.Background()
+// rather than a caller-supplied context, since Call has no context
+// parameter of its own.
+func (c *Client) Call(method string, params any, result any) error {
+	paramsBytes, err := c.codec.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal params for %s: %w", method, err)
+	}
+	return c.call(context.Background(), method, paramsBytes, result)
+}
+
+// CallWithMeta is Call, additionally attaching meta to the request's
+// params under a "_meta" key, per the MCP convention for per-call
+// metadata (such as a request id or tenant id for server-side log
+// correlation) that isn't part of the method's own parameters. It also
+// accepts ctx, returning ctx.Err() if it's done before a response arrives,
+// in addition to the client-wide ClientOptions.CallTimeout. Since _meta
+// lives inside params, params must marshal to a JSON object (or be nil).
+func (c *Client) CallWithMeta(ctx context.Context, method string, params any, result any, meta map[string]string) error {
+	paramsBytes, err := c.codec.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal params for %s: %w", method, err)
+	}
+	if len(meta) > 0 {
+		paramsMap := map[string]any{}
+		if len(paramsBytes) > 0 && string(paramsBytes) != "null" {
+			if err := c.codec.Unmarshal(paramsBytes, &paramsMap); err != nil {
+				return fmt.Errorf("codex/rpc: CallWithMeta requires object params to attach _meta for %s: %w", method, err)
+			}
+		}
+		paramsMap["_meta"] = meta
+		paramsBytes, err = c.codec.Marshal(paramsMap)
+		if err != nil {
+			return fmt.Errorf("codex/rpc: marshal params with _meta for %s: %w", method, err)
+		}
+	}
+	return c.call(ctx, method, paramsBytes, result)
+}
+
+// call is the shared implementation behind Call and CallWithMeta: it sends
+// a request with the given pre-marshaled params and decodes the response
+// into result, blocking until a response arrives, ctx is done, or
+// ClientOptions.CallTimeout elapses, whichever comes first.
+func (c *Client) call(ctx context.Context, method string, paramsBytes json.RawMessage, result any) error {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return fmt.Errorf("codex/rpc: rate limit wait for %s: %w", method, err)
+		}
+	}
+
+	_, span := c.tracer.Start(ctx, method)
+	defer span.End()
+
+	id := c.nextID()
+	idBytes, err := c.codec.Marshal(id)
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal request id: %w", err)
+	}
+	span.SetAttribute("rpc.requestId", string(idBytes))
+	span.SetAttribute("rpc.paramsSize", len(paramsBytes))
+
+	respCh := make(chan wireMessage, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errors.New("codex/rpc: client is closed")
+	}
+	if c.closing {
+		c.mu.Unlock()
+		return ErrClientClosing
+	}
+	c.pending[string(idBytes)] = respCh
+	c.pendingWG.Add(1)
+	c.mu.Unlock()
+	defer c.pendingWG.Done()
+
+	req := wireMessage{ID: idBytes, Method: method, Params: paramsBytes}
+	line, err := c.codec.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal request for %s: %w", method, err)
+	}
+	if err := c.writeLine(string(line)); err != nil {
+		return fmt.Errorf("codex/rpc: write request for %s: %w", method, err)
+	}
+
+	var timeoutCh <-chan time.Time
+	if c.callTimeout > 0 {
+		timer := time.NewTimer(c.callTimeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	var resp wireMessage
+	select {
+	case resp = <-respCh:
+	case <-timeoutCh:
+		c.mu.Lock()
+		delete(c.pending, string(idBytes))
+		c.mu.Unlock()
+		return &TimeoutError{Method: method, Elapsed: c.callTimeout}
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(idBytes))
+		c.mu.Unlock()
+		_ = c.notifyCancelled(id, ctx.Err())
+		return ctx.Err()
+	}
+	if resp.Error != nil {
+		return &RPCError{Method: method, Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := c.codec.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("codex/rpc: decode %s response: %w", method, err)
+	}
+	return nil
+}
+
+// notifyCancelled tells the server to stop working on a request the caller
+// gave up waiting on, per the MCP notifications/cancelled convention: a
+// notification carrying the abandoned request's id so the server can free
+// whatever it was doing on its behalf instead of finishing orphaned work.
+func (c *Client) notifyCancelled(id RequestID, cause error) error {
+	reason := ""
+	if cause != nil {
+		reason = cause.Error()
+	}
+	return c.Notify("notifications/cancelled", map[string]any{"requestId": id, "reason": reason})
+}
+
+// Notify sends a fire-and-forget notification to the server. Since Notify
+// has no context parameter of its own, a configured ClientOptions.RateLimiter
+// is waited on against context.Background(), so it can only block Notify,
+// never cancel it.
+func (c *Client) Notify(method string, params any) error {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(context.Background()); err != nil {
+			return fmt.Errorf("codex/rpc: rate limit wait for %s: %w", method, err)
+		}
+	}
+
+	paramsBytes, err := c.codec.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal params for %s: %w", method, err)
+	}
+	line, err := c.codec.Marshal(wireMessage{Method: method, Params: paramsBytes})
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal notification %s: %w", method, err)
+	}
+	return c.writeLine(string(line))
+}
+
+// SubscribeNotifications returns an iterator over server notifications. The
+// channel is buffered to buffer entries; a slow consumer blocks delivery
+// to other subscribers once it fills (OverflowBlock), so callers that
+// can't keep up should use a larger buffer, SubscribeNotificationsWithOptions
+// with OverflowDropOldest, or drain promptly.
+func (c *Client) SubscribeNotifications(buffer int) *NotificationIterator {
+	return c.SubscribeNotificationsFunc(buffer, nil)
+}
+
+// SubscribeNotificationsFunc is SubscribeNotifications, additionally
+// filtering at the source: when filter is non-nil, only notifications for
+// which it returns true are enqueued into this subscriber's channel. This
+// keeps a subscriber interested in one thread's notifications (the common
+// case) from being flooded, and from needing to do that filtering itself,
+// when many threads are running concurrently on one Client. A nil filter
+// enqueues every notification, matching SubscribeNotifications.
+func (c *Client) SubscribeNotificationsFunc(buffer int, filter func(Notification) bool) *NotificationIterator {
+	return c.SubscribeNotificationsWithOptions(SubscribeOptions{Buffer: buffer, Filter: filter})
+}
+
+// SubscribeOptions configures SubscribeNotificationsWithOptions.
+type SubscribeOptions struct {
+	// Buffer sizes the subscriber's notification channel.
+	Buffer int
+	// Filter, when non-nil, restricts delivery to notifications for which
+	// it returns true, same as SubscribeNotificationsFunc's filter.
+	Filter func(Notification) bool
+	// Overflow selects what happens when Buffer fills. The zero value,
+	// OverflowBlock, matches SubscribeNotifications/SubscribeNotificationsFunc.
+	Overflow Overflow
+}
+
+// SubscribeNotificationsWithOptions is SubscribeNotificationsFunc with
+// explicit control over what happens when a subscriber's buffer fills; see
+// Overflow. Each subscriber has its own channel and cursor into the
+// notification stream, so one subscriber falling behind, overflowing, or
+// closing its iterator never affects any other subscriber registered on
+// the same Client.
+func (c *Client) SubscribeNotificationsWithOptions(opts SubscribeOptions) *NotificationIterator {
+	ch := make(chan Notification, opts.Buffer)
+	c.mu.Lock()
+	c.subs = append(c.subs, subscription{ch: ch, filter: opts.Filter, overflow: opts.Overflow})
+	c.mu.Unlock()
+	return &NotificationIterator{client: c, ch: ch, done: make(chan struct{})}
+}
+
+func (c *Client) unsubscribe(ch chan Notification) {
+	c.mu.Lock()
+	for i, sub := range c.subs {
+		if sub.ch == ch {
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	// dispatchNotification may already be blocked sending to ch (the
+	// default OverflowBlock policy) if this subscriber fell behind right
+	// before being removed above. Since dispatch runs on the single read
+	// loop goroutine, that block would otherwise stall delivery to every
+	// other subscriber forever, because nothing will ever read from ch
+	// again once it's gone from c.subs. Draining it here completes that
+	// pending send (if any) so the read loop can move on.
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+// Done returns a channel that's closed once the client's read loop exits,
+// whether because the transport failed (subprocess crash, dropped socket)
+// or because Close was called. Callers that want to distinguish the two
+// should check whether they themselves called Close first.
+func (c *Client) Done() <-chan struct{} {
+	return c.readLoopDone
+}
+
+// Close shuts down the client's transport and read loop.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	err := c.transport.Close()
+	<-c.readLoopDone
+	return err
+}
+
+// CloseGracefully stops accepting new calls (Call immediately returns
+// ErrClientClosing) and waits for every call already in flight to receive
+// its response before closing the transport, so a server that wants to
+// finish its current turn before exiting isn't cut off mid-response. If
+// ctx expires first, it closes the transport anyway, same as Close, which
+// fails any calls still outstanding.
+func (c *Client) CloseGracefully(ctx context.Context) error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.pendingWG.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+	return c.Close()
+}
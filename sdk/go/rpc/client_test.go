@@ -0,0 +1,772 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientRepliesMethodNotFoundWithoutRequestHandler(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	transport.pushFromServer(`{"id":"srv-1","method":"item/commandExecution/requestApproval","params":{}}`)
+
+	line := transport.waitForWrite(t)
+	var resp wireMessage
+	if err := json.Unmarshal([]byte(line), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.Error == nil {
+		t.Fatalf("response = %+v, want an error", resp)
+	}
+	if resp.Error.Code != methodNotFoundCode {
+		t.Fatalf("Error.Code = %d, want %d", resp.Error.Code, methodNotFoundCode)
+	}
+}
+
+func TestClientRequestHandlerAnswersServerRequest(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{
+		RequestHandler: func(method string, params any) (any, error) {
+			if method != "item/commandExecution/requestApproval" {
+				return nil, ErrMethodNotFound
+			}
+			return map[string]any{"decision": "approve"}, nil
+		},
+	})
+	defer client.Close()
+
+	transport.pushFromServer(`{"id":"srv-1","method":"item/commandExecution/requestApproval","params":{}}`)
+
+	line := transport.waitForWrite(t)
+	var resp wireMessage
+	if err := json.Unmarshal([]byte(line), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("response = %+v, want no error", resp)
+	}
+	var result map[string]string
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if result["decision"] != "approve" {
+		t.Fatalf("result = %+v, want decision=approve", result)
+	}
+}
+
+func TestDispatchServerRequestDoesNotBlockNotificationDelivery(t *testing.T) {
+	transport := newFakeClientTransport()
+	handlerStarted := make(chan struct{})
+	release := make(chan struct{})
+	client := NewClient(transport, ClientOptions{
+		RequestHandler: func(method string, params any) (any, error) {
+			close(handlerStarted)
+			<-release
+			return map[string]any{"decision": "approve"}, nil
+		},
+	})
+	defer client.Close()
+
+	notes := client.SubscribeNotifications(1)
+
+	transport.pushFromServer(`{"id":"srv-1","method":"item/commandExecution/requestApproval","params":{}}`)
+	<-handlerStarted
+
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{}}`)
+
+	done := make(chan Notification, 1)
+	go func() {
+		note, _ := notes.Next()
+		done <- note
+	}()
+
+	select {
+	case note := <-done:
+		if note.Method != "turn/heartbeat" {
+			t.Fatalf("note.Method = %q, want turn/heartbeat", note.Method)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification delivery was blocked by a slow request handler")
+	}
+
+	close(release)
+	_ = transport.waitForWrite(t)
+}
+
+func TestDoneClosesWhenTransportFails(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+
+	select {
+	case <-client.Done():
+		t.Fatal("Done closed before the transport failed")
+	default:
+	}
+
+	close(transport.in)
+
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done did not close after the transport's ReadLine started failing")
+	}
+}
+
+func TestSubscribeNotificationsFuncOnlyEnqueuesMatching(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	notes := client.SubscribeNotificationsFunc(8, func(n Notification) bool {
+		return n.Method == "turn/completed"
+	})
+	defer notes.Close()
+
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{}}`)
+	transport.pushFromServer(`{"method":"turn/completed","params":{}}`)
+
+	note, ok := notes.Next()
+	if !ok {
+		t.Fatal("Next: want a notification")
+	}
+	if note.Method != "turn/completed" {
+		t.Fatalf("Method = %q, want turn/completed (the heartbeat should have been filtered out)", note.Method)
+	}
+}
+
+func TestSubscribersHaveIndependentCursorsAtDifferentRates(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	fast := client.SubscribeNotifications(8)
+	defer fast.Close()
+	slow := client.SubscribeNotifications(8)
+	defer slow.Close()
+
+	for i := 0; i < 3; i++ {
+		transport.pushFromServer(`{"method":"turn/heartbeat","params":{}}`)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, ok := fast.Next(); !ok {
+			t.Fatalf("fast.Next() #%d: want ok", i)
+		}
+	}
+
+	// slow hasn't read anything yet; its buffered notifications are still
+	// waiting, independent of fast having drained its own copy of each one.
+	if _, ok := slow.Next(); !ok {
+		t.Fatal("slow.Next(): want ok")
+	}
+}
+
+func TestCloseOnOneSubscriberDoesNotAffectAnother(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	a := client.SubscribeNotifications(4)
+	b := client.SubscribeNotifications(4)
+	defer b.Close()
+
+	a.Close()
+
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{}}`)
+
+	note, ok := b.Next()
+	if !ok || note.Method != "turn/heartbeat" {
+		t.Fatalf("b.Next() = %+v, %v, want turn/heartbeat, true", note, ok)
+	}
+}
+
+func TestOverflowDropOldestDiscardsOldestInsteadOfBlocking(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	notes := client.SubscribeNotificationsWithOptions(SubscribeOptions{Buffer: 2, Overflow: OverflowDropOldest})
+	defer notes.Close()
+
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{"n":1}}`)
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{"n":2}}`)
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{"n":3}}`)
+
+	// Give the read loop a moment to dispatch all three before we drain.
+	time.Sleep(20 * time.Millisecond)
+
+	var seen []float64
+	for i := 0; i < 2; i++ {
+		note, ok := notes.Next()
+		if !ok {
+			t.Fatalf("Next() #%d: want ok", i)
+		}
+		params, _ := note.Params.(map[string]any)
+		seen = append(seen, params["n"].(float64))
+	}
+	if len(seen) != 2 || seen[0] != 2 || seen[1] != 3 {
+		t.Fatalf("seen = %v, want [2 3] (n=1 dropped)", seen)
+	}
+}
+
+func TestUnsubscribeUnblocksDispatchWhenAPendingSendWasInFlight(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	// victim uses the default OverflowBlock policy with a buffer of one, so
+	// the second notification below leaves the read loop blocked trying to
+	// send to victim.ch with nobody reading it.
+	victim := client.SubscribeNotifications(1)
+	other := client.SubscribeNotifications(8)
+	defer other.Close()
+
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{"n":1}}`)
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{"n":2}}`)
+
+	// Give the read loop a moment to dispatch the first notification to both
+	// subscribers and then wedge itself delivering the second one to victim.
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		victim.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("victim.Close() did not return; unsubscribe left the dispatch goroutine wedged")
+	}
+
+	// If the read loop was really freed, other should still receive both
+	// notifications and the client should still answer calls.
+	for i := 0; i < 2; i++ {
+		if _, ok := other.Next(); !ok {
+			t.Fatalf("other.Next() #%d: want ok", i)
+		}
+	}
+
+	go func() {
+		req := transport.waitForWrite(t)
+		var wire wireMessage
+		_ = json.Unmarshal([]byte(req), &wire)
+		resp, _ := json.Marshal(map[string]any{"id": wire.ID, "result": map[string]any{}})
+		transport.pushFromServer(string(resp))
+	}()
+
+	callDone := make(chan error, 1)
+	go func() {
+		callDone <- client.Call("turn/start", nil, nil)
+	}()
+
+	select {
+	case err := <-callDone:
+		if err != nil {
+			t.Fatalf("Call after unsubscribe: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Call after unsubscribe did not return; client is wedged")
+	}
+}
+
+func TestCallTimesOutWhenNoResponseArrives(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{CallTimeout: 10 * time.Millisecond})
+	defer client.Close()
+
+	err := client.Call("turn/start", map[string]any{}, nil)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Call err = %v, want *TimeoutError", err)
+	}
+	if timeoutErr.Method != "turn/start" {
+		t.Fatalf("TimeoutError.Method = %q, want turn/start", timeoutErr.Method)
+	}
+}
+
+func TestCallWithoutTimeoutWaitsForResponse(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+
+	if err := client.Call("turn/start", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+}
+
+func TestCallWithMetaAttachesMetaUnderMetaKey(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	var sent string
+	go func() {
+		sent = transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(sent), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+
+	err := client.CallWithMeta(context.Background(), "turn/start", map[string]any{"threadId": "t1"}, nil, map[string]string{"requestId": "req-123"})
+	if err != nil {
+		t.Fatalf("CallWithMeta: %v", err)
+	}
+
+	var req struct {
+		Params struct {
+			ThreadID string            `json:"threadId"`
+			Meta     map[string]string `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(sent), &req); err != nil {
+		t.Fatalf("unmarshal sent request: %v", err)
+	}
+	if req.Params.ThreadID != "t1" {
+		t.Fatalf("threadId = %q, want t1", req.Params.ThreadID)
+	}
+	if req.Params.Meta["requestId"] != "req-123" {
+		t.Fatalf("_meta.requestId = %q, want req-123", req.Params.Meta["requestId"])
+	}
+}
+
+func TestCallWithMetaRespectsContextCancellation(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := client.CallWithMeta(ctx, "turn/start", map[string]any{}, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CallWithMeta err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCallSendsNotificationsCancelledOnContextCancellation(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- client.CallWithMeta(ctx, "turn/start", map[string]any{}, nil, nil) }()
+	requestLine := transport.waitForWrite(t)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Call err = %v, want context.Canceled", err)
+	}
+
+	var req wireMessage
+	if err := json.Unmarshal([]byte(requestLine), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+
+	cancelLine := transport.waitForWrite(t)
+	var note struct {
+		Method string `json:"method"`
+		Params struct {
+			RequestID json.RawMessage `json:"requestId"`
+			Reason    string          `json:"reason"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(cancelLine), &note); err != nil {
+		t.Fatalf("unmarshal notification: %v", err)
+	}
+	if note.Method != "notifications/cancelled" {
+		t.Fatalf("Method = %q, want notifications/cancelled", note.Method)
+	}
+	if string(note.Params.RequestID) != string(req.ID) {
+		t.Fatalf("requestId = %s, want %s", note.Params.RequestID, req.ID)
+	}
+	if note.Params.Reason == "" {
+		t.Fatal("reason should not be empty")
+	}
+}
+
+func TestCallWithMetaErrorsWhenParamsAreNotAnObject(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	err := client.CallWithMeta(context.Background(), "turn/start", []int{1, 2, 3}, nil, map[string]string{"a": "b"})
+	if err == nil {
+		t.Fatal("CallWithMeta: want an error when params isn't a JSON object")
+	}
+}
+
+// countingCodec wraps encoding/json, counting how many times each method is
+// called, so a test can confirm a custom Codec is actually reached rather
+// than the Client silently falling back to its encoding/json default.
+type countingCodec struct {
+	marshals   int
+	unmarshals int
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals++
+	return json.Unmarshal(data, v)
+}
+
+func TestClientUsesConfiguredCodecForRequestsAndResponses(t *testing.T) {
+	transport := newFakeClientTransport()
+	codec := &countingCodec{}
+	client := NewClient(transport, ClientOptions{Codec: codec})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{"ok":true}`)})))
+	}()
+
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	if err := client.Call("turn/start", map[string]any{}, &result); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if !result.OK {
+		t.Fatal("result.OK = false, want true")
+	}
+	if codec.marshals == 0 || codec.unmarshals == 0 {
+		t.Fatalf("codec = %+v, want both Marshal and Unmarshal to have been used", codec)
+	}
+}
+
+// countingRateLimiter records how many times Wait is called and with which
+// ctx, so a test can confirm Call/CallWithMeta/Notify actually consult a
+// configured RateLimiter rather than silently skipping it.
+type countingRateLimiter struct {
+	waits int
+	err   error
+}
+
+func (l *countingRateLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	if l.err != nil {
+		return l.err
+	}
+	return ctx.Err()
+}
+
+func TestClientConsultsRateLimiterBeforeCall(t *testing.T) {
+	transport := newFakeClientTransport()
+	limiter := &countingRateLimiter{}
+	client := NewClient(transport, ClientOptions{RateLimiter: limiter})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{"ok":true}`)})))
+	}()
+
+	if err := client.Call("turn/start", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if limiter.waits != 1 {
+		t.Fatalf("waits = %d, want 1", limiter.waits)
+	}
+
+	if err := client.Notify("turn/interrupt", map[string]any{}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if limiter.waits != 2 {
+		t.Fatalf("waits = %d, want 2 after Notify", limiter.waits)
+	}
+}
+
+func TestClientRateLimiterErrorFailsCall(t *testing.T) {
+	transport := newFakeClientTransport()
+	limiter := &countingRateLimiter{err: errors.New("rate limit exceeded")}
+	client := NewClient(transport, ClientOptions{RateLimiter: limiter})
+	defer client.Close()
+
+	err := client.Call("turn/start", map[string]any{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "rate limit exceeded") {
+		t.Fatalf("Call err = %v, want it to wrap the limiter's error", err)
+	}
+}
+
+func TestRequestIDFuncOverridesDefaultIntegerIDs(t *testing.T) {
+	transport := newFakeClientTransport()
+	ids := []RequestID{"req-a", "req-b"}
+	next := 0
+	client := NewClient(transport, ClientOptions{
+		RequestIDFunc: func() RequestID {
+			id := ids[next]
+			next++
+			return id
+		},
+	})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		if string(req.ID) != `"req-a"` {
+			t.Errorf("request id = %s, want %q", req.ID, "req-a")
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+	if err := client.Call("turn/start", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		if string(req.ID) != `"req-b"` {
+			t.Errorf("request id = %s, want %q", req.ID, "req-b")
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+	if err := client.Call("turn/continue", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+}
+
+func TestCallReturnsRPCErrorWithCodeAndData(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{
+			ID: req.ID,
+			Error: &wireError{
+				Code:    -32001,
+				Message: "sandbox unavailable",
+				Data:    json.RawMessage(`{"retryable":false}`),
+			},
+		})))
+	}()
+
+	err := client.Call("sandbox/validate", map[string]any{}, nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Call err = %v, want *RPCError", err)
+	}
+	if rpcErr.Method != "sandbox/validate" || rpcErr.Code != -32001 || rpcErr.Message != "sandbox unavailable" {
+		t.Fatalf("rpcErr = %+v, want Method sandbox/validate, Code -32001, Message %q", rpcErr, "sandbox unavailable")
+	}
+	if string(rpcErr.Data) != `{"retryable":false}` {
+		t.Fatalf("rpcErr.Data = %s, want {\"retryable\":false}", rpcErr.Data)
+	}
+}
+
+func TestCloseGracefullyWaitsForInFlightCallToRespond(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+
+	callDone := make(chan error, 1)
+	go func() {
+		callDone <- client.Call("turn/start", map[string]any{}, nil)
+	}()
+
+	line := transport.waitForWrite(t)
+	var req wireMessage
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+
+	closeDone := make(chan error, 1)
+	go func() {
+		closeDone <- client.CloseGracefully(context.Background())
+	}()
+
+	// New calls started after CloseGracefully began must be rejected
+	// immediately rather than queuing behind the in-flight one.
+	time.Sleep(10 * time.Millisecond)
+	if err := client.Call("turn/start", map[string]any{}, nil); !errors.Is(err, ErrClientClosing) {
+		t.Fatalf("Call during graceful close = %v, want ErrClientClosing", err)
+	}
+
+	transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+
+	if err := <-callDone; err != nil {
+		t.Fatalf("in-flight Call: %v", err)
+	}
+	if err := <-closeDone; err != nil {
+		t.Fatalf("CloseGracefully: %v", err)
+	}
+}
+
+func TestCloseGracefullyStopsWaitingOnceContextExpires(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+
+	go func() { _ = client.Call("turn/start", map[string]any{}, nil) }()
+	transport.waitForWrite(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := client.CloseGracefully(ctx); err != nil {
+		t.Fatalf("CloseGracefully: %v", err)
+	}
+}
+
+type recordingSpan struct {
+	attributes map[string]any
+	events     []string
+	ended      bool
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) { s.attributes[key] = value }
+func (s *recordingSpan) AddEvent(name string, _ map[string]any) {
+	s.events = append(s.events, name)
+}
+func (s *recordingSpan) End() { s.ended = true }
+
+type recordingTracer struct {
+	names []string
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordingSpan{attributes: map[string]any{}}
+	t.names = append(t.names, name)
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestCallStartsSpanNamedAfterMethodWithRequestAttributes(t *testing.T) {
+	transport := newFakeClientTransport()
+	tracer := &recordingTracer{}
+	client := NewClient(transport, ClientOptions{Tracer: tracer})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+			return
+		}
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+
+	if err := client.Call("turn/start", map[string]any{"foo": "bar"}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("len(spans) = %d, want 1", len(tracer.spans))
+	}
+	if tracer.names[0] != "turn/start" {
+		t.Fatalf("span name = %q, want turn/start", tracer.names[0])
+	}
+	span := tracer.spans[0]
+	if !span.ended {
+		t.Fatal("span was not ended")
+	}
+	if _, ok := span.attributes["rpc.requestId"]; !ok {
+		t.Fatal("span missing rpc.requestId attribute")
+	}
+	if _, ok := span.attributes["rpc.paramsSize"]; !ok {
+		t.Fatal("span missing rpc.paramsSize attribute")
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b
+}
+
+// fakeClientTransport is a minimal Transport for driving a real Client
+// through server-initiated requests without a subprocess.
+type fakeClientTransport struct {
+	in  chan string
+	out chan string
+}
+
+func newFakeClientTransport() *fakeClientTransport {
+	return &fakeClientTransport{in: make(chan string, 16), out: make(chan string, 16)}
+}
+
+func (t *fakeClientTransport) pushFromServer(line string) { t.in <- line }
+
+func (t *fakeClientTransport) waitForWrite(t2 *testing.T) string {
+	t2.Helper()
+	select {
+	case line := <-t.out:
+		return line
+	case <-time.After(time.Second):
+		t2.Fatal("timed out waiting for client to write a response")
+		return ""
+	}
+}
+
+func (t *fakeClientTransport) ReadLine() (string, error) {
+	line, ok := <-t.in
+	if !ok {
+		return "", io.EOF
+	}
+	return line, nil
+}
+
+func (t *fakeClientTransport) WriteLine(line string) error {
+	t.out <- line
+	return nil
+}
+
+func (t *fakeClientTransport) Close() error {
+	close(t.in)
+	return nil
+}
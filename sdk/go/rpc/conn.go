@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"bufio"
+	"io"
+	"net"
+)
+
+// connTransport implements Transport over a single net.Conn, reading and
+// writing newline-delimited JSON-RPC messages directly, with no framing of
+// its own (unlike websocketTransport, which wraps each line in a WebSocket
+// frame).
+type connTransport struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewConnTransport returns a Transport that reads and writes
+// newline-delimited JSON-RPC messages over conn. Use it with net.Dial to
+// connect to an app-server listening on a Unix domain socket or TCP port,
+// for example as a long-lived daemon shared by multiple SDK clients,
+// instead of spawning a fresh subprocess per client via SpawnStdio.
+func NewConnTransport(conn net.Conn) Transport {
+	return &connTransport{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (t *connTransport) ReadLine() (string, error) {
+	line, err := t.reader.ReadString('\n')
+	if err != nil {
+		if len(line) > 0 && err == io.EOF {
+			return trimNewline(line), nil
+		}
+		return "", err
+	}
+	return trimNewline(line), nil
+}
+
+func (t *connTransport) WriteLine(line string) error {
+	_, err := io.WriteString(t.conn, line+"\n")
+	return err
+}
+
+func (t *connTransport) Close() error {
+	return t.conn.Close()
+}
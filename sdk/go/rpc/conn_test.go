@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestConnTransportReadLineReadsUntilNewline(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	transport := NewConnTransport(client)
+	defer transport.Close()
+
+	go func() {
+		_, _ = server.Write([]byte("{\"method\":\"ping\"}\n"))
+	}()
+
+	line, err := transport.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if line != `{"method":"ping"}` {
+		t.Fatalf("ReadLine = %q, want the line without its trailing newline", line)
+	}
+}
+
+func TestConnTransportWriteLineAppendsNewline(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	transport := NewConnTransport(client)
+	defer transport.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := server.Read(buf)
+		done <- string(buf[:n])
+	}()
+
+	if err := transport.WriteLine(`{"method":"ping"}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if got := <-done; got != "{\"method\":\"ping\"}\n" {
+		t.Fatalf("server read = %q, want the line with a trailing newline", got)
+	}
+}
+
+func TestConnTransportCloseClosesUnderlyingConn(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	transport := NewConnTransport(client)
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Fatal("Write after Close: want an error on the closed conn")
+	}
+}
@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+)
+
+// base64PayloadPattern matches a long run of base64 alphabet characters,
+// the shape an inline image (or other large binary) payload takes once
+// embedded in a JSON string. It's a best-effort heuristic rather than a
+// JSON-aware redactor: short values and ordinary text are left alone.
+var base64PayloadPattern = regexp.MustCompile(`[A-Za-z0-9+/]{200,}={0,2}`)
+
+// redactFrame replaces any embedded base64 payload in line with a
+// placeholder noting its size, so ClientOptions.DebugFrames logging
+// doesn't flood logs (or leak sensitive image content) with raw bytes.
+func redactFrame(line string) string {
+	return base64PayloadPattern.ReplaceAllStringFunc(line, func(match string) string {
+		return fmt.Sprintf("<%d bytes redacted>", len(match))
+	})
+}
+
+// debugFrameLogger logs raw JSON-RPC frames at Debug level on behalf of a
+// Client with ClientOptions.DebugFrames set. A nil *debugFrameLogger is
+// valid and logs nothing, so callers don't need to nil-check before use.
+type debugFrameLogger struct {
+	logger *slog.Logger
+}
+
+func (d *debugFrameLogger) logSent(line string) {
+	if d == nil {
+		return
+	}
+	d.logger.Debug("codex/rpc: sent frame", "line", redactFrame(line))
+}
+
+func (d *debugFrameLogger) logReceived(line string) {
+	if d == nil {
+		return
+	}
+	d.logger.Debug("codex/rpc: received frame", "line", redactFrame(line))
+}
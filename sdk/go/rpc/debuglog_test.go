@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactFrameReplacesLongBase64Payload(t *testing.T) {
+	payload := strings.Repeat("A", 300)
+	line := `{"method":"item/delta","params":{"image":"` + payload + `"}}`
+
+	redacted := redactFrame(line)
+
+	if strings.Contains(redacted, payload) {
+		t.Fatal("redactFrame left the base64 payload intact")
+	}
+	if !strings.Contains(redacted, "bytes redacted") {
+		t.Fatalf("redactFrame = %q, want a redaction placeholder", redacted)
+	}
+	if !strings.Contains(redacted, `"method":"item/delta"`) {
+		t.Fatalf("redactFrame = %q, want the rest of the frame untouched", redacted)
+	}
+}
+
+func TestRedactFrameLeavesShortValuesAlone(t *testing.T) {
+	line := `{"id":1,"method":"turn/start","params":{"threadId":"thread-1"}}`
+	if redactFrame(line) != line {
+		t.Fatalf("redactFrame altered a frame with no large payload: %q", redactFrame(line))
+	}
+}
+
+func TestClientLogsSentAndReceivedFramesWhenDebugFramesEnabled(t *testing.T) {
+	transport := newFakeClientTransport()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := NewClient(transport, ClientOptions{DebugFrames: true, Logger: logger})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		_ = json.Unmarshal([]byte(line), &req)
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+
+	if err := client.Call("turn/start", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "sent frame") {
+		t.Fatalf("log output = %q, want a sent frame entry", out)
+	}
+	if !strings.Contains(out, "received frame") {
+		t.Fatalf("log output = %q, want a received frame entry", out)
+	}
+}
+
+func TestClientDoesNotLogFramesByDefault(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	go func() {
+		line := transport.waitForWrite(t)
+		var req wireMessage
+		_ = json.Unmarshal([]byte(line), &req)
+		transport.pushFromServer(string(mustMarshal(t, wireMessage{ID: req.ID, Result: json.RawMessage(`{}`)})))
+	}()
+
+	if err := client.Call("turn/start", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	// Nothing to assert beyond "this doesn't panic with a nil logger"; a
+	// nil *debugFrameLogger must be safe to call, since DebugFrames is off.
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+)
+
+// Overflow selects what a subscription does when its buffered channel is
+// full and the client has another notification to deliver to it.
+type Overflow int
+
+const (
+	// OverflowBlock makes dispatchNotification block until the subscriber
+	// drains its channel, the default and prior behavior. A slow
+	// subscriber with this policy can delay delivery to every other
+	// subscriber, since dispatch fans out from a single goroutine; a
+	// subscriber that can't keep up should either use a larger buffer or
+	// OverflowDropOldest instead.
+	OverflowBlock Overflow = iota
+	// OverflowDropOldest discards the subscription's oldest buffered
+	// notification to make room for the new one instead of blocking, so a
+	// slow subscriber falls behind on history rather than stalling
+	// dispatch to everyone else.
+	OverflowDropOldest
+)
+
+// subscription is one entry in Client.subs: the channel notifications are
+// delivered to, the optional filter deciding which ones qualify, and the
+// policy for handling a full channel.
+type subscription struct {
+	ch       chan Notification
+	filter   func(Notification) bool
+	overflow Overflow
+}
+
+// NotificationIterator yields notifications delivered to a single
+// subscriber registered via Client.SubscribeNotifications.
+type NotificationIterator struct {
+	client *Client
+	ch     chan Notification
+	// done is closed by Close, so a blocked Next/NextCtx wakes up and
+	// returns ok=false instead of waiting forever. The underlying ch is
+	// never closed: the read loop may still be mid-send to it (via a subs
+	// snapshot taken just before unsubscribe runs), and closing a channel
+	// with a pending sender would panic.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Next blocks until a notification arrives, or returns false once Close has
+// been called.
+func (it *NotificationIterator) Next() (Notification, bool) {
+	select {
+	case note, ok := <-it.ch:
+		return note, ok
+	default:
+	}
+	select {
+	case note, ok := <-it.ch:
+		return note, ok
+	case <-it.done:
+		return Notification{}, false
+	}
+}
+
+// NextCtx is Next, but also returns early with ctx.Err() if ctx is done
+// before a notification arrives. It selects directly on the iterator's
+// underlying channel rather than draining it into an intermediate
+// goroutine, so a notification that was already queued when ctx is done (or
+// when Close is called) is never consumed and discarded: it stays buffered
+// and is delivered to whichever call, Next or NextCtx, reads next,
+// including one made with a fresh context after this one returned early.
+func (it *NotificationIterator) NextCtx(ctx context.Context) (Notification, bool, error) {
+	select {
+	case note, ok := <-it.ch:
+		return note, ok, nil
+	default:
+	}
+	select {
+	case note, ok := <-it.ch:
+		return note, ok, nil
+	case <-it.done:
+		return Notification{}, false, nil
+	case <-ctx.Done():
+		return Notification{}, false, ctx.Err()
+	}
+}
+
+// Close stops delivery to this subscriber and wakes up any blocked
+// Next/NextCtx call so it returns ok=false, rather than leaving it to block
+// forever once nothing will ever send to ch again. It does not affect other
+// subscribers on the same Client. Close is safe to call more than once.
+func (it *NotificationIterator) Close() {
+	it.closeOnce.Do(func() {
+		it.client.unsubscribe(it.ch)
+		close(it.done)
+	})
+}
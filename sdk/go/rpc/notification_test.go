@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNextCtxReturnsCtxErrWhenNoNotificationArrives(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	notes := client.SubscribeNotifications(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, ok, err := notes.NextCtx(ctx)
+	if ok || err == nil {
+		t.Fatalf("NextCtx() = (_, %v, %v), want an error and ok=false", ok, err)
+	}
+}
+
+func TestNextCtxDoesNotDropABufferedNotificationOnCancel(t *testing.T) {
+	transport := newFakeClientTransport()
+	client := NewClient(transport, ClientOptions{})
+	defer client.Close()
+
+	notes := client.SubscribeNotifications(1)
+	transport.pushFromServer(`{"method":"turn/heartbeat","params":{}}`)
+
+	// Give the client's read loop a moment to dispatch the notification
+	// into the subscription's buffered channel before canceling, so the
+	// race this test cares about (a value sitting in the channel when ctx
+	// is already done) is actually exercised.
+	time.Sleep(20 * time.Millisecond)
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// NextCtx may still win the race and return the notification even with
+	// an already-canceled context (select doesn't prefer one ready case
+	// over another); what matters is that if it doesn't, the notification
+	// isn't lost.
+	note, ok, err := notes.NextCtx(canceledCtx)
+	if err == nil {
+		if !ok || note.Method != "turn/heartbeat" {
+			t.Fatalf("NextCtx() returned the notification malformed: %+v, %v", note, ok)
+		}
+		return
+	}
+
+	note, ok, err = notes.NextCtx(context.Background())
+	if err != nil {
+		t.Fatalf("NextCtx with a fresh context: %v", err)
+	}
+	if !ok || note.Method != "turn/heartbeat" {
+		t.Fatalf("note = %+v, ok = %v, want the buffered turn/heartbeat notification", note, ok)
+	}
+}
@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RecordTransport wraps a base Transport and records every line read from
+// or written to it, so the resulting transcript can later be fed to
+// NewReplayTransport.
+type RecordTransport struct {
+	base Transport
+
+	mu         sync.Mutex
+	transcript []TranscriptEntry
+}
+
+// NewRecordTransport wraps base, recording all traffic passing through it.
+func NewRecordTransport(base Transport) *RecordTransport {
+	return &RecordTransport{base: base}
+}
+
+// NewRercordTransport is a misspelled alias for NewRecordTransport, kept so
+// any existing callers using the typo don't break.
+//
+// Deprecated: use NewRecordTransport instead.
+func NewRercordTransport(base Transport) *RecordTransport {
+	return NewRecordTransport(base)
+}
+
+func (t *RecordTransport) ReadLine() (string, error) {
+	line, err := t.base.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	t.transcript = append(t.transcript, TranscriptEntry{Direction: Received, Line: line})
+	t.mu.Unlock()
+	return line, nil
+}
+
+func (t *RecordTransport) WriteLine(line string) error {
+	if err := t.base.WriteLine(line); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.transcript = append(t.transcript, TranscriptEntry{Direction: Sent, Line: line})
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *RecordTransport) Close() error {
+	return t.base.Close()
+}
+
+// Transcript returns a copy of the recorded entries in order.
+func (t *RecordTransport) Transcript() []TranscriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TranscriptEntry(nil), t.transcript...)
+}
+
+// FileRecordTransport wraps a base Transport and appends each line that
+// crosses it to a file, one JSON object per line, as traffic happens.
+// Unlike RecordTransport, it never buffers the transcript in memory, so a
+// long session can be captured without it growing without bound. The file
+// it writes can be read back with LoadTranscriptFile for use with
+// NewReplayTransport.
+type FileRecordTransport struct {
+	base Transport
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecordTransportToFile wraps base, appending every line to a new file
+// at path (truncating it if it already exists) as traffic passes through.
+func NewRecordTransportToFile(base Transport, path string) (*FileRecordTransport, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("codex/rpc: create transcript file %s: %w", path, err)
+	}
+	return &FileRecordTransport{base: base, file: file}, nil
+}
+
+func (t *FileRecordTransport) ReadLine() (string, error) {
+	line, err := t.base.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if err := t.appendEntry(TranscriptEntry{Direction: Received, Line: line}); err != nil {
+		return "", err
+	}
+	return line, nil
+}
+
+func (t *FileRecordTransport) WriteLine(line string) error {
+	if err := t.base.WriteLine(line); err != nil {
+		return err
+	}
+	return t.appendEntry(TranscriptEntry{Direction: Sent, Line: line})
+}
+
+// Close closes the transcript file, then the base transport.
+func (t *FileRecordTransport) Close() error {
+	t.mu.Lock()
+	fileErr := t.file.Close()
+	t.mu.Unlock()
+	if baseErr := t.base.Close(); baseErr != nil {
+		return baseErr
+	}
+	return fileErr
+}
+
+func (t *FileRecordTransport) appendEntry(entry TranscriptEntry) error {
+	wire := transcriptEntryWire{Direction: directionToWire(entry.Direction), Line: entry.Line}
+	data, err := json.Marshal(wire)
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal transcript entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.file.Write(data); err != nil {
+		return fmt.Errorf("codex/rpc: write transcript entry: %w", err)
+	}
+	return nil
+}
+
+// LoadTranscriptFile reads back a transcript previously written by
+// NewRecordTransportToFile, in a form ready for NewReplayTransport.
+func LoadTranscriptFile(path string) ([]TranscriptEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("codex/rpc: read transcript file %s: %w", path, err)
+	}
+	var entries []TranscriptEntry
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var wire transcriptEntryWire
+		if err := json.Unmarshal([]byte(line), &wire); err != nil {
+			return nil, fmt.Errorf("codex/rpc: decode transcript file %s: %w", path, err)
+		}
+		direction, err := directionFromWire(wire.Direction)
+		if err != nil {
+			return nil, fmt.Errorf("codex/rpc: transcript file %s: %w", path, err)
+		}
+		entries = append(entries, TranscriptEntry{Direction: direction, Line: wire.Line})
+	}
+	return entries, nil
+}
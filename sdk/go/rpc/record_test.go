@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewRercordTransportIsEquivalentToNewRecordTransport(t *testing.T) {
+	transcript := []TranscriptEntry{
+		{Direction: Sent, Line: `{"id":1,"method":"turn/start","params":{}}`},
+		{Direction: Received, Line: `{"id":1,"result":{}}`},
+	}
+
+	want := NewRecordTransport(NewReplayTransport(transcript))
+	got := NewRercordTransport(NewReplayTransport(transcript))
+
+	if err := want.WriteLine(`{"id":1,"method":"turn/start","params":{}}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if err := got.WriteLine(`{"id":1,"method":"turn/start","params":{}}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if len(want.Transcript()) != len(got.Transcript()) {
+		t.Fatalf("Transcript() = %v, want equivalent to %v", got.Transcript(), want.Transcript())
+	}
+	if want.Transcript()[0] != got.Transcript()[0] {
+		t.Fatalf("Transcript()[0] = %+v, want %+v", got.Transcript()[0], want.Transcript()[0])
+	}
+}
+
+func TestFileRecordTransportWritesEntriesLoadableByReplayTransport(t *testing.T) {
+	base := NewReplayTransport([]TranscriptEntry{
+		{Direction: Sent, Line: `{"id":1,"method":"turn/start","params":{}}`},
+		{Direction: Received, Line: `{"id":1,"result":{}}`},
+	})
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := NewRecordTransportToFile(base, path)
+	if err != nil {
+		t.Fatalf("NewRecordTransportToFile: %v", err)
+	}
+
+	if err := recorder.WriteLine(`{"id":1,"method":"turn/start","params":{}}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	line, err := recorder.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if line != `{"id":1,"result":{}}` {
+		t.Fatalf("ReadLine = %q, want the base transport's response", line)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	entries, err := LoadTranscriptFile(path)
+	if err != nil {
+		t.Fatalf("LoadTranscriptFile: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+	if entries[0].Direction != Sent || entries[0].Line != `{"id":1,"method":"turn/start","params":{}}` {
+		t.Fatalf("entries[0] = %+v, want the sent request", entries[0])
+	}
+	if entries[1].Direction != Received || entries[1].Line != `{"id":1,"result":{}}` {
+		t.Fatalf("entries[1] = %+v, want the received response", entries[1])
+	}
+
+	replay := NewReplayTransport(entries)
+	if err := replay.WriteLine(`{"id":1,"method":"turn/start","params":{}}`); err != nil {
+		t.Fatalf("replay WriteLine: %v", err)
+	}
+	replayed, err := replay.ReadLine()
+	if err != nil {
+		t.Fatalf("replay ReadLine: %v", err)
+	}
+	if replayed != `{"id":1,"result":{}}` {
+		t.Fatalf("replay ReadLine = %q, want the recorded response", replayed)
+	}
+}
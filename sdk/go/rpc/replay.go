@@ -0,0 +1,79 @@
+package rpc
+
+import "io"
+
+// TranscriptDirection distinguishes which side of the connection produced a
+// TranscriptEntry.
+type TranscriptDirection int
+
+const (
+	// Sent marks a line the client wrote to the transport.
+	Sent TranscriptDirection = iota
+	// Received marks a line the client read from the transport.
+	Received
+)
+
+// TranscriptEntry is a single recorded line, tagged with its direction.
+type TranscriptEntry struct {
+	Direction TranscriptDirection
+	Line      string
+}
+
+// ReplayTransport is a Transport backed by a fixed transcript, used to drive
+// the Client deterministically in tests without a real subprocess. Writes
+// are checked against the expected Sent entries in order; reads are served
+// from the expected Received entries.
+type ReplayTransport struct {
+	entries []TranscriptEntry
+	pos     int
+}
+
+// NewReplayTransport returns a Transport that replays entries: WriteLine
+// must match the next Sent entry, and ReadLine returns the next Received
+// entry.
+func NewReplayTransport(entries []TranscriptEntry) *ReplayTransport {
+	return &ReplayTransport{entries: entries}
+}
+
+func (t *ReplayTransport) ReadLine() (string, error) {
+	for t.pos < len(t.entries) {
+		entry := t.entries[t.pos]
+		if entry.Direction != Received {
+			return "", io.EOF
+		}
+		t.pos++
+		return entry.Line, nil
+	}
+	return "", io.EOF
+}
+
+func (t *ReplayTransport) WriteLine(line string) error {
+	if t.pos >= len(t.entries) {
+		return &replayMismatchError{reason: "no more entries", got: line}
+	}
+	entry := t.entries[t.pos]
+	if entry.Direction != Sent {
+		return &replayMismatchError{reason: "expected a read, got a write", got: line}
+	}
+	if entry.Line != line {
+		return &replayMismatchError{reason: "unexpected write", want: entry.Line, got: line}
+	}
+	t.pos++
+	return nil
+}
+
+func (t *ReplayTransport) Close() error {
+	return nil
+}
+
+type replayMismatchError struct {
+	reason    string
+	want, got string
+}
+
+func (e *replayMismatchError) Error() string {
+	if e.want == "" {
+		return "codex/rpc: replay mismatch: " + e.reason + ": " + e.got
+	}
+	return "codex/rpc: replay mismatch: " + e.reason + ": want " + e.want + ", got " + e.got
+}
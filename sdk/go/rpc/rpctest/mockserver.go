@@ -0,0 +1,163 @@
+// Package rpctest provides an rpc.Transport for testing the SDK against a
+// scripted server, as an alternative to hand-building an exact
+// []rpc.TranscriptEntry transcript with rpc.NewReplayTransport.
+package rpctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CallHandler answers one RPC call registered with MockServer.OnCall. It
+// receives the call's raw params and returns the result to send back, or
+// an error to report to the client as a JSON-RPC error response.
+type CallHandler func(params json.RawMessage) (any, error)
+
+// MockServer is an rpc.Transport that plays the app-server's side of the
+// connection under a test's control: register a CallHandler per method by
+// name, and push notifications at any time with Notify, instead of
+// replaying an exact byte-for-byte transcript.
+type MockServer struct {
+	mu       sync.Mutex
+	handlers map[string]CallHandler
+	closed   bool
+
+	out chan string
+}
+
+// NewMockServer returns a MockServer ready to be passed to rpc.NewClient.
+func NewMockServer() *MockServer {
+	return &MockServer{
+		handlers: make(map[string]CallHandler),
+		out:      make(chan string, 64),
+	}
+}
+
+// OnCall registers handler to answer calls to method, replacing any
+// handler previously registered for it. A call to a method with no
+// registered handler gets a "method not found" error response.
+func (s *MockServer) OnCall(method string, handler CallHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// Notify pushes a server-initiated notification to the client. It can be
+// called from inside a CallHandler (to notify before answering the call
+// that triggered it) or independently, such as from another goroutine
+// simulating an out-of-band server push.
+func (s *MockServer) Notify(method string, params any) {
+	paramsBytes, err := json.Marshal(params)
+	if err != nil {
+		panic(fmt.Sprintf("rpctest: marshal params for %s: %v", method, err))
+	}
+	line, err := json.Marshal(wireMessage{Method: method, Params: paramsBytes})
+	if err != nil {
+		panic(fmt.Sprintf("rpctest: marshal notification %s: %v", method, err))
+	}
+	s.enqueue(string(line))
+}
+
+// ReadLine implements rpc.Transport, returning the next queued response or
+// notification, blocking until one is available or the server is closed.
+func (s *MockServer) ReadLine() (string, error) {
+	line, ok := <-s.out
+	if !ok {
+		return "", io.EOF
+	}
+	return line, nil
+}
+
+// WriteLine implements rpc.Transport. A request (a message with an id)
+// is dispatched to its registered CallHandler, if any, and the handler's
+// result or error is enqueued as the response; a notification from the
+// client (no id) is accepted and discarded, since MockServer has no
+// client-side notifications to react to today.
+func (s *MockServer) WriteLine(line string) error {
+	var msg wireMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return fmt.Errorf("rpctest: decode request: %w", err)
+	}
+	if len(msg.ID) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	handler := s.handlers[msg.Method]
+	s.mu.Unlock()
+
+	if handler == nil {
+		s.enqueueError(msg.ID, -32601, fmt.Sprintf("method not found: %s", msg.Method))
+		return nil
+	}
+
+	result, err := handler(msg.Params)
+	if err != nil {
+		s.enqueueError(msg.ID, -32000, err.Error())
+		return nil
+	}
+	s.enqueueResult(msg.ID, result)
+	return nil
+}
+
+// Close implements rpc.Transport, unblocking any ReadLine call in
+// progress. It is safe to call more than once.
+func (s *MockServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.out)
+	return nil
+}
+
+func (s *MockServer) enqueueResult(id json.RawMessage, result any) {
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		s.enqueueError(id, -32000, fmt.Sprintf("marshal result: %v", err))
+		return
+	}
+	line, err := json.Marshal(wireMessage{ID: id, Result: resultBytes})
+	if err != nil {
+		return
+	}
+	s.enqueue(string(line))
+}
+
+func (s *MockServer) enqueueError(id json.RawMessage, code int, message string) {
+	line, err := json.Marshal(wireMessage{ID: id, Error: &wireError{Code: code, Message: message}})
+	if err != nil {
+		return
+	}
+	s.enqueue(string(line))
+}
+
+func (s *MockServer) enqueue(line string) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+	s.out <- line
+}
+
+// wireMessage mirrors the JSON-RPC line shape rpc.Client speaks, so
+// MockServer can decode requests and encode responses without depending on
+// rpc's unexported wire types.
+type wireMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *wireError      `json:"error,omitempty"`
+}
+
+type wireError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,105 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+var errBoom = errors.New("boom")
+
+func TestOnCallAnswersRegisteredMethod(t *testing.T) {
+	srv := NewMockServer()
+	defer srv.Close()
+	client := rpc.NewClient(srv, rpc.ClientOptions{})
+	defer client.Close()
+
+	srv.OnCall("turn/start", func(params json.RawMessage) (any, error) {
+		return map[string]any{"turnId": "turn-1"}, nil
+	})
+
+	var result struct {
+		TurnID string `json:"turnId"`
+	}
+	if err := client.Call("turn/start", map[string]any{"threadId": "thread-1"}, &result); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if result.TurnID != "turn-1" {
+		t.Fatalf("TurnID = %q, want turn-1", result.TurnID)
+	}
+}
+
+func TestOnCallCanNotifyBeforeAnswering(t *testing.T) {
+	srv := NewMockServer()
+	defer srv.Close()
+	client := rpc.NewClient(srv, rpc.ClientOptions{})
+	defer client.Close()
+
+	notes := client.SubscribeNotifications(4)
+	defer notes.Close()
+
+	srv.OnCall("turn/start", func(params json.RawMessage) (any, error) {
+		srv.Notify("turn/completed", map[string]any{"turn": map[string]any{"status": "completed"}})
+		return map[string]any{}, nil
+	})
+
+	if err := client.Call("turn/start", nil, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	select {
+	case note := <-waitForNotification(notes):
+		if note.Method != "turn/completed" {
+			t.Fatalf("Method = %q, want turn/completed", note.Method)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for turn/completed notification")
+	}
+}
+
+func TestCallToUnregisteredMethodReturnsMethodNotFound(t *testing.T) {
+	srv := NewMockServer()
+	defer srv.Close()
+	client := rpc.NewClient(srv, rpc.ClientOptions{})
+	defer client.Close()
+
+	err := client.Call("turn/start", nil, nil)
+	var rpcErr *rpc.RPCError
+	if err == nil {
+		t.Fatal("Call: want an error for an unregistered method")
+	}
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("Call err = %v, want *rpc.RPCError with code -32601", err)
+	}
+}
+
+func TestHandlerErrorBecomesRPCError(t *testing.T) {
+	srv := NewMockServer()
+	defer srv.Close()
+	client := rpc.NewClient(srv, rpc.ClientOptions{})
+	defer client.Close()
+
+	srv.OnCall("turn/start", func(params json.RawMessage) (any, error) {
+		return nil, errBoom
+	})
+
+	err := client.Call("turn/start", nil, nil)
+	var rpcErr *rpc.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Message != errBoom.Error() {
+		t.Fatalf("Call err = %v, want *rpc.RPCError wrapping %q", err, errBoom)
+	}
+}
+
+func waitForNotification(it *rpc.NotificationIterator) <-chan rpc.Notification {
+	ch := make(chan rpc.Notification, 1)
+	go func() {
+		note, ok := it.Next()
+		if ok {
+			ch <- note
+		}
+	}()
+	return ch
+}
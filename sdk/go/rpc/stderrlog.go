@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// stderrLineLogger splits a subprocess's stderr stream into lines and logs
+// each one via logger at level, for SpawnOptions.LogStderr. Lines are
+// buffered until a newline arrives, so a write that splits a line across
+// two Write calls still logs it whole.
+type stderrLineLogger struct {
+	logger *slog.Logger
+	level  slog.Level
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func newStderrLineLogger(logger *slog.Logger, level slog.Level) *stderrLineLogger {
+	return &stderrLineLogger{logger: logger, level: level}
+}
+
+func (w *stderrLineLogger) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// No newline yet; put the partial line back and wait for more.
+			w.buf.WriteString(line)
+			break
+		}
+		w.logger.Log(context.Background(), w.level, "app-server stderr", "line", strings.TrimSuffix(line, "\n"))
+	}
+	return len(p), nil
+}
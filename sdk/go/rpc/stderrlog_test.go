@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestStderrLineLoggerLogsCompleteLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	w := newStderrLineLogger(logger, slog.LevelWarn)
+
+	if _, err := w.Write([]byte("first line\nsecond line\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "first line") || !strings.Contains(out, "second line") {
+		t.Fatalf("log output = %q, want both lines logged", out)
+	}
+	if strings.Count(out, "app-server stderr") != 2 {
+		t.Fatalf("log output = %q, want exactly 2 log entries", out)
+	}
+}
+
+func TestStderrLineLoggerBuffersPartialLineAcrossWrites(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	w := newStderrLineLogger(logger, slog.LevelWarn)
+
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("log output = %q, want nothing logged before the newline arrives", buf.String())
+	}
+
+	if _, err := w.Write([]byte(" line\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "partial line") {
+		t.Fatalf("log output = %q, want the reassembled line", buf.String())
+	}
+}
@@ -0,0 +1,37 @@
+package rpc
+
+// TapTransport wraps base, invoking onRead/onWrite with the raw bytes of
+// each line as it crosses the wire, before any JSON parsing happens. This
+// is for live, unfiltered byte-level inspection during development (for
+// example, diagnosing framing issues); for recording a transcript to
+// replay later, use RecordTransport instead.
+type TapTransport struct {
+	base    Transport
+	onRead  func([]byte)
+	onWrite func([]byte)
+}
+
+// NewTapTransport wraps base with onRead/onWrite callbacks. Either
+// callback may be nil to ignore that direction.
+func NewTapTransport(base Transport, onRead, onWrite func([]byte)) *TapTransport {
+	return &TapTransport{base: base, onRead: onRead, onWrite: onWrite}
+}
+
+func (t *TapTransport) ReadLine() (string, error) {
+	line, err := t.base.ReadLine()
+	if err == nil && t.onRead != nil {
+		t.onRead([]byte(line))
+	}
+	return line, err
+}
+
+func (t *TapTransport) WriteLine(line string) error {
+	if t.onWrite != nil {
+		t.onWrite([]byte(line))
+	}
+	return t.base.WriteLine(line)
+}
+
+func (t *TapTransport) Close() error {
+	return t.base.Close()
+}
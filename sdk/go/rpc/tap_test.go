@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"io"
+	"testing"
+)
+
+type fixedLineTransport struct {
+	readLines []string
+	readIdx   int
+	written   []string
+}
+
+func (t *fixedLineTransport) ReadLine() (string, error) {
+	if t.readIdx >= len(t.readLines) {
+		return "", io.EOF
+	}
+	line := t.readLines[t.readIdx]
+	t.readIdx++
+	return line, nil
+}
+
+func (t *fixedLineTransport) WriteLine(line string) error {
+	t.written = append(t.written, line)
+	return nil
+}
+
+func (t *fixedLineTransport) Close() error { return nil }
+
+func TestTapTransportInvokesCallbacksWithRawBytes(t *testing.T) {
+	base := &fixedLineTransport{readLines: []string{`{"method":"turn/heartbeat"}`}}
+
+	var read, written []byte
+	tap := NewTapTransport(base, func(b []byte) { read = b }, func(b []byte) { written = b })
+
+	line, err := tap.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if line != `{"method":"turn/heartbeat"}` {
+		t.Fatalf("ReadLine = %q", line)
+	}
+	if string(read) != line {
+		t.Fatalf("onRead saw %q, want %q", read, line)
+	}
+
+	if err := tap.WriteLine(`{"id":1,"result":{}}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	if string(written) != `{"id":1,"result":{}}` {
+		t.Fatalf("onWrite saw %q", written)
+	}
+	if len(base.written) != 1 || base.written[0] != `{"id":1,"result":{}}` {
+		t.Fatalf("base did not receive the write: %v", base.written)
+	}
+}
+
+func TestTapTransportToleratesNilCallbacks(t *testing.T) {
+	base := &fixedLineTransport{readLines: []string{`{"method":"turn/heartbeat"}`}}
+	tap := NewTapTransport(base, nil, nil)
+
+	if _, err := tap.ReadLine(); err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if err := tap.WriteLine(`{}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package rpc
+
+import "context"
+
+// Span is a single traced operation, matching the minimal subset of
+// OpenTelemetry's trace.Span that this package needs. A caller already
+// using OpenTelemetry can satisfy this interface with a thin adapter
+// around an otel trace.Span, without this SDK vendoring the
+// go.opentelemetry.io/otel dependency itself (this module depends only on
+// the standard library).
+type Span interface {
+	// SetAttribute records a single key/value attribute on the span.
+	SetAttribute(key string, value any)
+	// AddEvent records a timestamped event on the span, such as a
+	// notification observed during a Call or a turn.
+	AddEvent(name string, attributes map[string]any)
+	// End marks the span as finished.
+	End()
+}
+
+// Tracer starts spans for traced operations, matching the minimal subset
+// of OpenTelemetry's trace.Tracer that this package needs.
+type Tracer interface {
+	// Start begins a new span named name, returning a context carrying it
+	// alongside the span itself, mirroring OpenTelemetry's
+	// trace.Tracer.Start(ctx, name) (context.Context, trace.Span).
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// NoopTracer returns a Tracer whose spans record nothing. It's the
+// default for ClientOptions.Tracer, so tracing costs nothing until a
+// caller supplies a real Tracer.
+func NoopTracer() Tracer { return noopTracer{} }
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, any)        {}
+func (noopSpan) AddEvent(string, map[string]any) {}
+func (noopSpan) End()                            {}
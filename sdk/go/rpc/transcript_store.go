@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TranscriptStore loads and saves named transcripts, so test authors can
+// manage a directory of golden-file transcripts instead of passing
+// []TranscriptEntry literals around by hand.
+type TranscriptStore interface {
+	// Save writes entries under name, overwriting any existing transcript.
+	Save(name string, entries []TranscriptEntry) error
+	// Load reads back the transcript previously saved under name.
+	Load(name string) ([]TranscriptEntry, error)
+}
+
+// FileTranscriptStore is a TranscriptStore backed by one JSON file per
+// transcript, named "<name>.json" inside Dir.
+type FileTranscriptStore struct {
+	Dir string
+}
+
+// Save writes entries to <Dir>/<name>.json, creating Dir if necessary.
+func (s FileTranscriptStore) Save(name string, entries []TranscriptEntry) error {
+	wire := make([]transcriptEntryWire, len(entries))
+	for i, entry := range entries {
+		wire[i] = transcriptEntryWire{Direction: directionToWire(entry.Direction), Line: entry.Line}
+	}
+	data, err := json.MarshalIndent(wire, "", "  ")
+	if err != nil {
+		return fmt.Errorf("codex/rpc: marshal transcript %s: %w", name, err)
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("codex/rpc: create transcript dir %s: %w", s.Dir, err)
+	}
+	if err := os.WriteFile(s.path(name), data, 0o644); err != nil {
+		return fmt.Errorf("codex/rpc: write transcript %s: %w", name, err)
+	}
+	return nil
+}
+
+// Load reads back the transcript previously saved under name.
+func (s FileTranscriptStore) Load(name string) ([]TranscriptEntry, error) {
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("codex/rpc: read transcript %s: %w", name, err)
+	}
+	var wire []transcriptEntryWire
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, fmt.Errorf("codex/rpc: decode transcript %s: %w", name, err)
+	}
+	entries := make([]TranscriptEntry, len(wire))
+	for i, w := range wire {
+		direction, err := directionFromWire(w.Direction)
+		if err != nil {
+			return nil, fmt.Errorf("codex/rpc: transcript %s: %w", name, err)
+		}
+		entries[i] = TranscriptEntry{Direction: direction, Line: w.Line}
+	}
+	return entries, nil
+}
+
+func (s FileTranscriptStore) path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
+
+type transcriptEntryWire struct {
+	Direction string `json:"direction"`
+	Line      string `json:"line"`
+}
+
+func directionToWire(d TranscriptDirection) string {
+	if d == Sent {
+		return "sent"
+	}
+	return "received"
+}
+
+func directionFromWire(s string) (TranscriptDirection, error) {
+	switch s {
+	case "sent":
+		return Sent, nil
+	case "received":
+		return Received, nil
+	default:
+		return 0, fmt.Errorf("unknown transcript direction %q", s)
+	}
+}
+
+// RecordTranscriptsEnvVar is the environment variable that, when set to a
+// non-empty value, tells test helpers built on TranscriptStore to
+// re-record golden transcripts instead of replaying the saved ones.
+const RecordTranscriptsEnvVar = "CODEX_RECORD_TRANSCRIPTS"
+
+// ShouldRecordTranscripts reports whether RecordTranscriptsEnvVar is set.
+func ShouldRecordTranscripts() bool {
+	return os.Getenv(RecordTranscriptsEnvVar) != ""
+}
@@ -0,0 +1,35 @@
+package rpc
+
+import "testing"
+
+func TestFileTranscriptStoreRoundTrip(t *testing.T) {
+	store := FileTranscriptStore{Dir: t.TempDir()}
+	entries := []TranscriptEntry{
+		{Direction: Sent, Line: `{"id":1,"method":"initialize"}`},
+		{Direction: Received, Line: `{"id":1,"result":{}}`},
+	}
+
+	if err := store.Save("initialize", entries); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := store.Load("initialize")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("Load returned %d entries, want %d", len(got), len(entries))
+	}
+	for i, entry := range entries {
+		if got[i] != entry {
+			t.Fatalf("entry %d = %+v, want %+v", i, got[i], entry)
+		}
+	}
+}
+
+func TestFileTranscriptStoreLoadMissing(t *testing.T) {
+	store := FileTranscriptStore{Dir: t.TempDir()}
+	if _, err := store.Load("does-not-exist"); err == nil {
+		t.Fatalf("expected an error loading a missing transcript")
+	}
+}
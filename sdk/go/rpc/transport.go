@@ -0,0 +1,174 @@
+// Package rpc implements the newline-delimited JSON-RPC transport used to
+// talk to the codex app-server, plus a minimal client on top of it.
+package rpc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Transport reads and writes single JSON-RPC messages, one per line. It is
+// the seam Client is built on, so tests can swap a subprocess for an
+// in-memory replay or record implementation.
+type Transport interface {
+	// ReadLine blocks until a full line is available, returning it without
+	// the trailing newline.
+	ReadLine() (string, error)
+	// WriteLine writes line followed by a newline.
+	WriteLine(line string) error
+	// Close releases any resources (subprocess, socket, file) held by the
+	// transport. It is safe to call more than once.
+	Close() error
+}
+
+// SpawnOptions configures a subprocess-backed Transport created by SpawnStdio.
+type SpawnOptions struct {
+	// Args are additional arguments passed to the app-server binary, after
+	// the implicit "app-server" subcommand.
+	Args []string
+	// Env, when non-nil, replaces the subprocess environment entirely.
+	Env []string
+	// Stderr, when set, receives the subprocess's standard error stream.
+	Stderr io.Writer
+	// IdleTimeout, when greater than zero, closes the transport and kills
+	// the subprocess if neither ReadLine nor WriteLine observes any
+	// activity for that long, so a caller that forgets to call
+	// Client.Close doesn't leak a zombie app-server process forever. Zero
+	// (the default) never times out.
+	IdleTimeout time.Duration
+	// LogStderr, when true, pipes the subprocess's stderr into Logger
+	// line-by-line at StderrLevel, as well as into Stderr if that's also
+	// set, so subprocess diagnostics (and crashes) show up in a caller's
+	// structured logs without requiring a separate writer to inspect.
+	LogStderr bool
+	// StderrLevel is the level stderr lines are logged at when LogStderr
+	// is set. Zero selects the default, slog.LevelWarn.
+	StderrLevel slog.Level
+	// Logger receives stderr lines when LogStderr is set. Defaults to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	reader *bufio.Reader
+
+	idleTimeout time.Duration
+	idleMu      sync.Mutex
+	idleTimer   *time.Timer
+}
+
+// SpawnStdio starts path as a subprocess and returns a Transport that speaks
+// JSON-RPC over its stdin/stdout.
+func SpawnStdio(path string, opts SpawnOptions) (Transport, error) {
+	args := append([]string{"app-server"}, opts.Args...)
+	cmd := exec.Command(path, args...)
+	if opts.Env != nil {
+		cmd.Env = opts.Env
+	}
+	var stderrWriters []io.Writer
+	if opts.Stderr != nil {
+		stderrWriters = append(stderrWriters, opts.Stderr)
+	}
+	if opts.LogStderr {
+		logger := opts.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		level := opts.StderrLevel
+		if level == 0 {
+			level = slog.LevelWarn
+		}
+		stderrWriters = append(stderrWriters, newStderrLineLogger(logger, level))
+	}
+	switch len(stderrWriters) {
+	case 0:
+	case 1:
+		cmd.Stderr = stderrWriters[0]
+	default:
+		cmd.Stderr = io.MultiWriter(stderrWriters...)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("codex: create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("codex: create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("codex: spawn %s: %w", path, err)
+	}
+
+	t := &stdioTransport{
+		cmd:         cmd,
+		stdin:       stdin,
+		reader:      bufio.NewReader(stdout),
+		idleTimeout: opts.IdleTimeout,
+	}
+	if t.idleTimeout > 0 {
+		t.idleTimer = time.AfterFunc(t.idleTimeout, func() { _ = t.Close() })
+	}
+	return t, nil
+}
+
+// resetIdleTimer restarts the idle timeout clock after observed ReadLine or
+// WriteLine activity. It's a no-op when SpawnOptions.IdleTimeout wasn't set.
+func (t *stdioTransport) resetIdleTimer() {
+	if t.idleTimeout <= 0 {
+		return
+	}
+	t.idleMu.Lock()
+	defer t.idleMu.Unlock()
+	if t.idleTimer != nil {
+		t.idleTimer.Reset(t.idleTimeout)
+	}
+}
+
+func (t *stdioTransport) ReadLine() (string, error) {
+	line, err := t.reader.ReadString('\n')
+	t.resetIdleTimer()
+	if err != nil {
+		if len(line) > 0 && err == io.EOF {
+			return trimNewline(line), nil
+		}
+		return "", err
+	}
+	return trimNewline(line), nil
+}
+
+func (t *stdioTransport) WriteLine(line string) error {
+	_, err := io.WriteString(t.stdin, line+"\n")
+	t.resetIdleTimer()
+	return err
+}
+
+func (t *stdioTransport) Close() error {
+	t.idleMu.Lock()
+	if t.idleTimer != nil {
+		t.idleTimer.Stop()
+	}
+	t.idleMu.Unlock()
+	_ = t.stdin.Close()
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	return t.cmd.Wait()
+}
+
+func trimNewline(line string) string {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line
+}
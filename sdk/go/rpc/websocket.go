@@ -0,0 +1,279 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// websocketGUID is the fixed GUID RFC 6455 defines for computing
+// Sec-WebSocket-Accept from the client's Sec-WebSocket-Key.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketOptions configures DialWebSocket.
+type WebSocketOptions struct {
+	// Header carries additional HTTP headers sent with the handshake
+	// request, such as an Authorization bearer token for a hosted
+	// app-server.
+	Header http.Header
+}
+
+// DialWebSocket connects to a remote app-server over a WebSocket, returning
+// a Transport that frames each JSON-RPC message (one produced by
+// Client.Call/Notify, or one read by Client's read loop) as a single
+// WebSocket text frame. url's scheme must be "ws" or "wss". The handshake
+// honors ctx's cancellation and deadline; once established, ReadLine and
+// WriteLine do not take a context, matching the rest of this package's
+// Transport implementations.
+//
+// This is a minimal RFC 6455 client: it supports unfragmented text frames
+// only, and does not answer ping frames or negotiate extensions. That's
+// enough for the app-server's newline-delimited JSON-RPC traffic, which
+// this package always sends as complete, independent messages.
+func DialWebSocket(ctx context.Context, rawURL string, opts WebSocketOptions) (Transport, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("codex/rpc: parse websocket url %q: %w", rawURL, err)
+	}
+
+	var tlsConfig *tls.Config
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		tlsConfig = &tls.Config{ServerName: u.Hostname()}
+	default:
+		return nil, fmt.Errorf("codex/rpc: unsupported websocket scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("codex/rpc: dial %s: %w", addr, err)
+	}
+	if tlsConfig != nil {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = tlsConn.SetDeadline(deadline)
+		}
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("codex/rpc: tls handshake with %s: %w", addr, err)
+		}
+		_ = tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	reader, err := performWebSocketHandshake(conn, u, opts)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return &websocketTransport{conn: conn, reader: reader}, nil
+}
+
+// performWebSocketHandshake sends the HTTP upgrade request and validates
+// the server's response, returning the buffered reader http.ReadResponse
+// consumed the response from: it may already hold bytes belonging to the
+// first frame, so the caller must keep reading from it rather than conn
+// directly.
+func performWebSocketHandshake(conn net.Conn, u *url.URL, opts WebSocketOptions) (*bufio.Reader, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("codex/rpc: generate websocket key: %w", err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+
+	var req bytes.Buffer
+	requestURI := u.RequestURI()
+	if requestURI == "" {
+		requestURI = "/"
+	}
+	fmt.Fprintf(&req, "GET %s HTTP/1.1\r\n", requestURI)
+	fmt.Fprintf(&req, "Host: %s\r\n", u.Host)
+	req.WriteString("Upgrade: websocket\r\n")
+	req.WriteString("Connection: Upgrade\r\n")
+	fmt.Fprintf(&req, "Sec-WebSocket-Key: %s\r\n", encodedKey)
+	req.WriteString("Sec-WebSocket-Version: 13\r\n")
+	for name, values := range opts.Header {
+		for _, value := range values {
+			fmt.Fprintf(&req, "%s: %s\r\n", name, value)
+		}
+	}
+	req.WriteString("\r\n")
+
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return nil, fmt.Errorf("codex/rpc: send websocket handshake: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return nil, fmt.Errorf("codex/rpc: read websocket handshake response: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("codex/rpc: websocket handshake rejected: %s", resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return nil, fmt.Errorf("codex/rpc: websocket handshake response missing Upgrade: websocket header")
+	}
+	wantAccept := computeWebSocketAccept(encodedKey)
+	if resp.Header.Get("Sec-WebSocket-Accept") != wantAccept {
+		return nil, fmt.Errorf("codex/rpc: websocket handshake response has an unexpected Sec-WebSocket-Accept")
+	}
+	return reader, nil
+}
+
+func computeWebSocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte(websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+)
+
+// websocketTransport implements Transport over a single WebSocket
+// connection, framing each ReadLine/WriteLine as one text frame.
+type websocketTransport struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (t *websocketTransport) ReadLine() (string, error) {
+	for {
+		opcode, payload, err := readWebSocketFrame(t.reader)
+		if err != nil {
+			return "", err
+		}
+		switch opcode {
+		case wsOpText:
+			return string(payload), nil
+		case wsOpClose:
+			return "", io.EOF
+		default:
+			continue
+		}
+	}
+}
+
+func (t *websocketTransport) WriteLine(line string) error {
+	return writeWebSocketFrame(t.conn, wsOpText, []byte(line))
+}
+
+func (t *websocketTransport) Close() error {
+	_ = writeWebSocketFrame(t.conn, wsOpClose, nil)
+	return t.conn.Close()
+}
+
+// readWebSocketFrame reads one unfragmented WebSocket frame, returning its
+// opcode and unmasked payload.
+func readWebSocketFrame(r *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(r, mask); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// writeWebSocketFrame writes one unfragmented, masked WebSocket frame, as
+// RFC 6455 requires of every client-to-server frame.
+func writeWebSocketFrame(w io.Writer, opcode byte, payload []byte) error {
+	var frame bytes.Buffer
+	frame.WriteByte(0x80 | opcode) // FIN + opcode, no fragmentation
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		frame.WriteByte(0x80 | byte(length)) // MASK bit set
+	case length <= 0xFFFF:
+		frame.WriteByte(0x80 | 126)
+		ext := make([]byte, 2)
+		binary.BigEndian.PutUint16(ext, uint16(length))
+		frame.Write(ext)
+	default:
+		frame.WriteByte(0x80 | 127)
+		ext := make([]byte, 8)
+		binary.BigEndian.PutUint64(ext, uint64(length))
+		frame.Write(ext)
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return fmt.Errorf("codex/rpc: generate websocket frame mask: %w", err)
+	}
+	frame.Write(mask)
+	masked := make([]byte, length)
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	frame.Write(masked)
+
+	_, err := w.Write(frame.Bytes())
+	return err
+}
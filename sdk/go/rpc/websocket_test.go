@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// serveOneWebSocketHandshake accepts a single connection on ln, performs
+// just enough of the server side of the RFC 6455 handshake to satisfy
+// DialWebSocket, and returns the raw net.Conn for the test to drive
+// directly with readWebSocketFrame/writeWebSocketFrame.
+func serveOneWebSocketHandshake(t *testing.T, ln net.Listener) net.Conn {
+	t.Helper()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		t.Fatalf("read handshake request: %v", err)
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	accept := computeWebSocketAccept(key)
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		t.Fatalf("write handshake response: %v", err)
+	}
+	return conn
+}
+
+func TestDialWebSocketRoundTripsTextFrames(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	serverConnCh := make(chan net.Conn, 1)
+	go func() { serverConnCh <- serveOneWebSocketHandshake(t, ln) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	transport, err := DialWebSocket(ctx, "ws://"+ln.Addr().String()+"/app-server", WebSocketOptions{})
+	if err != nil {
+		t.Fatalf("DialWebSocket: %v", err)
+	}
+	defer transport.Close()
+
+	serverConn := <-serverConnCh
+	defer serverConn.Close()
+
+	if err := transport.WriteLine(`{"id":1,"method":"initialize"}`); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	opcode, payload, err := readWebSocketFrame(bufio.NewReader(serverConn))
+	if err != nil {
+		t.Fatalf("server read frame: %v", err)
+	}
+	if opcode != wsOpText {
+		t.Fatalf("opcode = %d, want text", opcode)
+	}
+	if string(payload) != `{"id":1,"method":"initialize"}` {
+		t.Fatalf("payload = %q, want the written line", payload)
+	}
+
+	if err := writeWebSocketFrame(serverConn, wsOpText, []byte(`{"id":1,"result":{}}`)); err != nil {
+		t.Fatalf("server write frame: %v", err)
+	}
+	line, err := transport.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	if line != `{"id":1,"result":{}}` {
+		t.Fatalf("ReadLine = %q, want the server's response", line)
+	}
+}
+
+func TestDialWebSocketRejectsNonSwitchingProtocolsResponse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := DialWebSocket(ctx, "ws://"+ln.Addr().String(), WebSocketOptions{}); err == nil {
+		t.Fatal("DialWebSocket: want error for a non-101 handshake response")
+	}
+}
@@ -0,0 +1,119 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RuleAction is the decision an ApprovalRule maps its match to.
+type RuleAction string
+
+const (
+	// RuleActionApprove approves the request outright.
+	RuleActionApprove RuleAction = "approve"
+	// RuleActionDeny denies the request outright.
+	RuleActionDeny RuleAction = "deny"
+	// RuleActionAsk defers the decision to RuleBasedApprovalHandler.Fallback,
+	// the same as an unmatched request.
+	RuleActionAsk RuleAction = "ask"
+)
+
+// ApprovalRule matches an approval request against one condition: a
+// command prefix for a command execution request, or a file-path glob
+// (path.Match syntax) for a file change request. Only one of
+// CommandPrefix or PathGlob should be set; whichever applies is checked
+// against the corresponding request type, and the rule is skipped for the
+// other.
+type ApprovalRule struct {
+	// CommandPrefix, when set, matches a command execution request whose
+	// Command starts with this prefix.
+	CommandPrefix string
+	// PathGlob, when set, matches a file change request if any of its
+	// Paths matches this glob.
+	PathGlob string
+	// Action is the decision to apply when this rule matches.
+	Action RuleAction
+}
+
+// RuleBasedApprovalHandler decides approvals by checking Rules in order
+// and applying the first match's Action. RuleActionAsk, and a request that
+// matches no rule, both fall through to Fallback if set, otherwise to
+// Default.
+type RuleBasedApprovalHandler struct {
+	// Rules are checked in order; the first match wins.
+	Rules []ApprovalRule
+	// Default is returned when no rule matches and Fallback is nil.
+	Default ApprovalDecision
+	// Fallback, when set, is asked to decide whenever a rule says
+	// RuleActionAsk or no rule matches, instead of using Default.
+	Fallback ApprovalHandler
+}
+
+func (h RuleBasedApprovalHandler) ItemCommandExecutionRequestApproval(ctx context.Context, params CommandExecutionApprovalParams) (ApprovalDecision, error) {
+	for _, rule := range h.Rules {
+		if rule.CommandPrefix == "" || !strings.HasPrefix(params.Command, rule.CommandPrefix) {
+			continue
+		}
+		return h.resolve(rule.Action, func() (ApprovalDecision, error) {
+			return h.Fallback.ItemCommandExecutionRequestApproval(ctx, params)
+		})
+	}
+	return h.resolve(RuleActionAsk, func() (ApprovalDecision, error) {
+		return h.Fallback.ItemCommandExecutionRequestApproval(ctx, params)
+	})
+}
+
+func (h RuleBasedApprovalHandler) ItemFileChangeRequestApproval(ctx context.Context, params FileChangeApprovalParams) (ApprovalDecision, error) {
+	for _, rule := range h.Rules {
+		if rule.PathGlob == "" || !anyPathMatches(rule.PathGlob, params.Paths) {
+			continue
+		}
+		return h.resolve(rule.Action, func() (ApprovalDecision, error) {
+			return h.Fallback.ItemFileChangeRequestApproval(ctx, params)
+		})
+	}
+	return h.resolve(RuleActionAsk, func() (ApprovalDecision, error) {
+		return h.Fallback.ItemFileChangeRequestApproval(ctx, params)
+	})
+}
+
+// ItemToolRequestUserInput has no rule to match against, so it always
+// defers to Fallback, or reports that it can't answer if there is none.
+func (h RuleBasedApprovalHandler) ItemToolRequestUserInput(ctx context.Context, params ToolUserInputApprovalParams) (string, error) {
+	if h.Fallback != nil {
+		return h.Fallback.ItemToolRequestUserInput(ctx, params)
+	}
+	return "", fmt.Errorf("%w: RuleBasedApprovalHandler cannot answer tool-requested user input without a Fallback", ErrNotImplemented)
+}
+
+// resolve applies action: RuleActionApprove/RuleActionDeny decide
+// directly, while RuleActionAsk (and a call with no matching rule, which
+// also routes through RuleActionAsk) defers to ask via Fallback, falling
+// back to Default if Fallback is nil.
+func (h RuleBasedApprovalHandler) resolve(action RuleAction, ask func() (ApprovalDecision, error)) (ApprovalDecision, error) {
+	switch action {
+	case RuleActionApprove:
+		return ApprovalDecisionApprove, nil
+	case RuleActionDeny:
+		return ApprovalDecisionDeny, nil
+	default:
+		if h.Fallback != nil {
+			return ask()
+		}
+		if h.Default != "" {
+			return h.Default, nil
+		}
+		return "", fmt.Errorf("%w: no rule matched and no Fallback or Default configured", ErrNotImplemented)
+	}
+}
+
+func anyPathMatches(glob string, paths []string) bool {
+	for _, p := range paths {
+		if ok, err := path.Match(glob, p); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRuleBasedApprovalHandlerMatchesCommandPrefix(t *testing.T) {
+	h := RuleBasedApprovalHandler{
+		Rules: []ApprovalRule{
+			{CommandPrefix: "rm ", Action: RuleActionDeny},
+			{CommandPrefix: "go test", Action: RuleActionApprove},
+		},
+		Default: ApprovalDecisionDeny,
+	}
+
+	decision, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "go test ./..."})
+	if err != nil {
+		t.Fatalf("ItemCommandExecutionRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionApprove {
+		t.Fatalf("decision = %q, want approve", decision)
+	}
+
+	decision, err = h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "rm -rf /"})
+	if err != nil {
+		t.Fatalf("ItemCommandExecutionRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionDeny {
+		t.Fatalf("decision = %q, want deny", decision)
+	}
+}
+
+func TestRuleBasedApprovalHandlerMatchesFilePathGlob(t *testing.T) {
+	h := RuleBasedApprovalHandler{
+		Rules: []ApprovalRule{
+			{PathGlob: "*.md", Action: RuleActionApprove},
+		},
+		Default: ApprovalDecisionDeny,
+	}
+
+	decision, err := h.ItemFileChangeRequestApproval(context.Background(), FileChangeApprovalParams{Paths: []string{"README.md"}})
+	if err != nil {
+		t.Fatalf("ItemFileChangeRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionApprove {
+		t.Fatalf("decision = %q, want approve", decision)
+	}
+
+	decision, err = h.ItemFileChangeRequestApproval(context.Background(), FileChangeApprovalParams{Paths: []string{"main.go"}})
+	if err != nil {
+		t.Fatalf("ItemFileChangeRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionDeny {
+		t.Fatalf("decision = %q, want deny (Default, no rule matched)", decision)
+	}
+}
+
+func TestRuleBasedApprovalHandlerUnmatchedFallsThroughToFallback(t *testing.T) {
+	fallback := &recordingApprovalHandler{decision: ApprovalDecisionApprove}
+	h := RuleBasedApprovalHandler{
+		Rules:    []ApprovalRule{{CommandPrefix: "rm ", Action: RuleActionDeny}},
+		Fallback: fallback,
+	}
+
+	decision, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "npm install"})
+	if err != nil {
+		t.Fatalf("ItemCommandExecutionRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionApprove {
+		t.Fatalf("decision = %q, want approve from Fallback", decision)
+	}
+	if !fallback.commandCalled {
+		t.Fatal("Fallback.ItemCommandExecutionRequestApproval was not called")
+	}
+}
+
+func TestRuleBasedApprovalHandlerAskActionDefersToFallback(t *testing.T) {
+	fallback := &recordingApprovalHandler{decision: ApprovalDecisionDeny}
+	h := RuleBasedApprovalHandler{
+		Rules:    []ApprovalRule{{CommandPrefix: "curl ", Action: RuleActionAsk}},
+		Fallback: fallback,
+	}
+
+	decision, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "curl https://example.com"})
+	if err != nil {
+		t.Fatalf("ItemCommandExecutionRequestApproval: %v", err)
+	}
+	if decision != ApprovalDecisionDeny {
+		t.Fatalf("decision = %q, want deny from Fallback", decision)
+	}
+	if !fallback.commandCalled {
+		t.Fatal("Fallback.ItemCommandExecutionRequestApproval was not called")
+	}
+}
+
+func TestRuleBasedApprovalHandlerWithoutFallbackOrDefaultErrors(t *testing.T) {
+	h := RuleBasedApprovalHandler{}
+	_, err := h.ItemCommandExecutionRequestApproval(context.Background(), CommandExecutionApprovalParams{Command: "echo hi"})
+	if !errors.Is(err, ErrNotImplemented) {
+		t.Fatalf("err = %v, want ErrNotImplemented", err)
+	}
+}
+
+type recordingApprovalHandler struct {
+	decision          ApprovalDecision
+	commandCalled     bool
+	lastCommandParams CommandExecutionApprovalParams
+	lastCommandCtx    context.Context
+}
+
+func (h *recordingApprovalHandler) ItemCommandExecutionRequestApproval(ctx context.Context, params CommandExecutionApprovalParams) (ApprovalDecision, error) {
+	h.commandCalled = true
+	h.lastCommandParams = params
+	h.lastCommandCtx = ctx
+	return h.decision, nil
+}
+
+func (h *recordingApprovalHandler) ItemFileChangeRequestApproval(context.Context, FileChangeApprovalParams) (ApprovalDecision, error) {
+	return h.decision, nil
+}
+
+func (h *recordingApprovalHandler) ItemToolRequestUserInput(context.Context, ToolUserInputApprovalParams) (string, error) {
+	return "", ErrNotImplemented
+}
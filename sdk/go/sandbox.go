@@ -0,0 +1,51 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReadOnlySandbox returns the sandbox policy that permits reading but not
+// writing to the filesystem, for use with Codex.ValidateSandbox. It matches
+// the app-server's "read-only" policy shape, sparing callers from having
+// to hand-write the map and risk a typo in the type string.
+func ReadOnlySandbox() any {
+	return map[string]any{"type": "read-only"}
+}
+
+// WorkspaceWriteSandbox returns the sandbox policy that additionally grants
+// write access to writableRoots (beyond the turn's own working directory),
+// for use with Codex.ValidateSandbox.
+func WorkspaceWriteSandbox(writableRoots ...string) any {
+	policy := map[string]any{"type": "workspace-write"}
+	if len(writableRoots) > 0 {
+		policy["writable_roots"] = writableRoots
+	}
+	return policy
+}
+
+// DangerFullAccessSandbox returns the sandbox policy with no restrictions
+// whatsoever, for use with Codex.ValidateSandbox. As the name warns, use
+// with caution.
+func DangerFullAccessSandbox() any {
+	return map[string]any{"type": "danger-full-access"}
+}
+
+// ValidateSandbox asks the app-server whether policy is valid and
+// enforceable on the current machine (for example, that writable roots
+// exist and the requested network mode is supported), without running a
+// turn. It returns nil if the policy is acceptable, or an error describing
+// what's wrong.
+func (c *Codex) ValidateSandbox(ctx context.Context, policy any) error {
+	var resp map[string]any
+	if err := c.currentClient().Call("sandbox/validate", map[string]any{"policy": policy}, &resp); err != nil {
+		return fmt.Errorf("codex: sandbox/validate: %w", err)
+	}
+	if valid, ok := resp["valid"].(bool); ok && !valid {
+		if reason, _ := resp["reason"].(string); reason != "" {
+			return fmt.Errorf("%w: %s", ErrInvalidSandboxPolicy, reason)
+		}
+		return ErrInvalidSandboxPolicy
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package codex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestReadOnlySandboxShape(t *testing.T) {
+	policy, ok := ReadOnlySandbox().(map[string]any)
+	if !ok || policy["type"] != "read-only" {
+		t.Fatalf("ReadOnlySandbox() = %v, want type read-only", policy)
+	}
+}
+
+func TestWorkspaceWriteSandboxOmitsWritableRootsWhenNoneGiven(t *testing.T) {
+	policy, ok := WorkspaceWriteSandbox().(map[string]any)
+	if !ok || policy["type"] != "workspace-write" {
+		t.Fatalf("WorkspaceWriteSandbox() = %v, want type workspace-write", policy)
+	}
+	if _, present := policy["writable_roots"]; present {
+		t.Fatalf("WorkspaceWriteSandbox() = %v, want no writable_roots key when none given", policy)
+	}
+}
+
+func TestWorkspaceWriteSandboxIncludesWritableRoots(t *testing.T) {
+	policy, ok := WorkspaceWriteSandbox("/tmp/scratch", "/workspace/data").(map[string]any)
+	if !ok {
+		t.Fatalf("WorkspaceWriteSandbox() = %v, want a map", policy)
+	}
+	roots, ok := policy["writable_roots"].([]string)
+	if !ok || len(roots) != 2 || roots[0] != "/tmp/scratch" || roots[1] != "/workspace/data" {
+		t.Fatalf("writable_roots = %v, want the given roots", policy["writable_roots"])
+	}
+}
+
+func TestDangerFullAccessSandboxShape(t *testing.T) {
+	policy, ok := DangerFullAccessSandbox().(map[string]any)
+	if !ok || policy["type"] != "danger-full-access" {
+		t.Fatalf("DangerFullAccessSandbox() = %v, want type danger-full-access", policy)
+	}
+}
+
+func TestValidateSandboxAcceptsTypedBuilderPolicy(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	transport.push(`{"id":1,"result":{"valid":true}}`)
+
+	if err := c.ValidateSandbox(context.Background(), ReadOnlySandbox()); err != nil {
+		t.Fatalf("ValidateSandbox: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package codex
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// stderrWatcher tees the app-server subprocess's stderr into an internal
+// buffer and signals the first time anything is written to it. New uses
+// this to fail fast on an early config/auth error instead of waiting for
+// initialize to time out.
+type stderrWatcher struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	ready chan struct{}
+	once  sync.Once
+}
+
+// writer returns an io.Writer to pass as SpawnOptions.Stderr: everything
+// written is captured and also forwarded to user, if non-nil.
+func (w *stderrWatcher) writer(user io.Writer) io.Writer {
+	w.ready = make(chan struct{})
+	return &stderrTee{watcher: w, user: user}
+}
+
+// wroteAny is closed the first time any bytes arrive on stderr.
+func (w *stderrWatcher) wroteAny() <-chan struct{} {
+	return w.ready
+}
+
+// String returns everything captured so far.
+func (w *stderrWatcher) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+type stderrTee struct {
+	watcher *stderrWatcher
+	user    io.Writer
+}
+
+func (t *stderrTee) Write(p []byte) (int, error) {
+	t.watcher.mu.Lock()
+	t.watcher.buf.Write(p)
+	t.watcher.mu.Unlock()
+	t.watcher.once.Do(func() { close(t.watcher.ready) })
+
+	if t.user != nil {
+		return t.user.Write(p)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,36 @@
+package codex
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestStderrWatcherSignalsOnFirstWrite(t *testing.T) {
+	var user bytes.Buffer
+	var w stderrWatcher
+	writer := w.writer(&user)
+
+	select {
+	case <-w.wroteAny():
+		t.Fatalf("wroteAny() fired before anything was written")
+	default:
+	}
+
+	if _, err := writer.Write([]byte("error: missing auth\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	select {
+	case <-w.wroteAny():
+	case <-time.After(time.Second):
+		t.Fatalf("wroteAny() did not fire after a write")
+	}
+
+	if w.String() != "error: missing auth\n" {
+		t.Fatalf("String() = %q, want the written content", w.String())
+	}
+	if user.String() != "error: missing auth\n" {
+		t.Fatalf("user buffer = %q, want the written content forwarded", user.String())
+	}
+}
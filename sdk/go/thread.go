@@ -0,0 +1,508 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// ThreadStartOptions configures a new thread.
+type ThreadStartOptions struct {
+	// Model overrides the default model for this thread.
+	Model string
+	// WorkingDirectory is the directory the agent operates in.
+	WorkingDirectory string
+	// MCPServers are the MCP tool servers this thread should connect to, in
+	// addition to any configured globally for the app-server.
+	MCPServers []MCPServerConfig
+	// ApprovalPolicy overrides when the agent pauses to ask for approval on
+	// this thread and its turns. It's sent as-is if set.
+	ApprovalPolicy ApprovalPolicy
+	// Config carries additional raw config overrides not yet covered by a
+	// typed field.
+	Config map[string]any
+}
+
+// MCPTransportType selects how the app-server talks to an MCP server.
+type MCPTransportType string
+
+const (
+	// MCPTransportStdio launches Command as a subprocess and speaks MCP over
+	// its stdin/stdout, the default when TransportType is left empty.
+	MCPTransportStdio MCPTransportType = "stdio"
+	// MCPTransportHTTP connects to an already-running MCP server over
+	// streamable HTTP; Command carries its URL instead of a launch command.
+	MCPTransportHTTP MCPTransportType = "http"
+)
+
+// MCPServerConfig declares an MCP tool server a thread should connect to.
+type MCPServerConfig struct {
+	// Name identifies this server; tool calls are namespaced under it.
+	Name string
+	// Command is the executable to launch (MCPTransportStdio) or the URL to
+	// connect to (MCPTransportHTTP).
+	Command string
+	// Args are passed to Command. Only meaningful for MCPTransportStdio.
+	Args []string
+	// Env sets additional environment variables for Command. Only
+	// meaningful for MCPTransportStdio.
+	Env map[string]string
+	// TransportType selects how to connect to this server. Empty means
+	// MCPTransportStdio.
+	TransportType MCPTransportType
+}
+
+// ThreadResumeOptions configures resuming a previously started thread.
+type ThreadResumeOptions struct {
+	// Path, when set, resumes from a rollout file on disk instead of by
+	// thread id.
+	Path string
+	// History, when set, seeds the resumed thread with this prior
+	// conversation instead of whatever the server has on file.
+	History []any
+	// HistoryLimit, when greater than zero, caps History to its last
+	// HistoryLimit items before serializing, keeping the most recent
+	// conversation turns and dropping the oldest. It has no effect when
+	// History is empty, and is independent of Path: when both Path and
+	// History are set, the limit still trims the inline History sent
+	// alongside the rollout path.
+	HistoryLimit int
+}
+
+// Thread represents a conversation with the agent. A thread can have
+// multiple consecutive turns.
+type Thread struct {
+	codex *Codex
+	id    string
+
+	// model and workingDirectory are the values the app-server actually
+	// confirmed for this thread, which may differ from what was requested
+	// (for example, an empty ThreadStartOptions.Model resolves to whatever
+	// the server's default is).
+	model            string
+	workingDirectory string
+	// items holds any pre-existing history the server returned when this
+	// thread was resumed. It is nil for a freshly started thread.
+	items []any
+	// rolloutPath is the on-disk rollout file the app-server recorded for
+	// this thread, if any. It's empty for an ephemeral thread.
+	rolloutPath string
+	// logger is this thread's structured logger, a child of Options.Logger
+	// (or slog.Default()) with a "threadId" field baked in. Read through
+	// Logger.
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	deleted bool
+}
+
+// checkNotDeleted returns ErrThreadDeleted if Delete has already removed
+// this thread's server-side state.
+func (t *Thread) checkNotDeleted() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.deleted {
+		return ErrThreadDeleted
+	}
+	return nil
+}
+
+// ThreadStartStream pairs a just-started Thread with the notifications the
+// app-server emitted while starting it, returned by Codex.StartThreadStreamed.
+// Call Next to read them and Close when done, the same contract as any other
+// notification stream; it never ends on its own, since a thread can keep
+// connecting to MCP servers in the background after thread/start returns.
+type ThreadStartStream struct {
+	Thread *Thread
+
+	iterator *rpc.NotificationIterator
+	threadID string
+}
+
+// Next blocks for the next setup notification belonging to this thread, or
+// returns false once Close has been called.
+func (s *ThreadStartStream) Next() (rpc.Notification, bool) {
+	for {
+		note, ok := s.iterator.Next()
+		if !ok {
+			return rpc.Notification{}, false
+		}
+		if !matchesThreadID(note, s.threadID) {
+			continue
+		}
+		return note, true
+	}
+}
+
+// Close stops delivery to this stream. It does not affect the Thread, which
+// remains usable.
+func (s *ThreadStartStream) Close() {
+	s.iterator.Close()
+}
+
+// ID returns the thread's identifier, as assigned by the app-server.
+func (t *Thread) ID() string {
+	return t.id
+}
+
+// Model returns the model the app-server confirmed for this thread.
+func (t *Thread) Model() string {
+	return t.model
+}
+
+// WorkingDirectory returns the cwd the app-server confirmed for this
+// thread.
+func (t *Thread) WorkingDirectory() string {
+	return t.workingDirectory
+}
+
+// Items returns any pre-existing history the app-server returned when this
+// thread was resumed. It is nil for a freshly started thread.
+func (t *Thread) Items() []any {
+	return t.items
+}
+
+// RolloutPath returns the on-disk rollout file the app-server recorded for
+// this thread, or "" for an ephemeral thread that wasn't persisted.
+// Resuming later via ThreadResumeOptions.Path needs exactly this value.
+func (t *Thread) RolloutPath() string {
+	return t.rolloutPath
+}
+
+// Logger returns this thread's structured logger, a child of
+// Options.Logger (or slog.Default()) with a "threadId" field baked in, so
+// log lines from many concurrently running threads can be told apart.
+func (t *Thread) Logger() *slog.Logger {
+	return resolveLogger(t.logger)
+}
+
+// Run sends inputs to the agent and blocks until the turn completes.
+func (t *Thread) Run(ctx context.Context, inputs ...Input) (*TurnResult, error) {
+	return t.RunWithOptions(ctx, TurnOptions{}, inputs...)
+}
+
+// RunWithOptions is Run with additional turn configuration, such as
+// TurnOptions.OnItem. If opts.RetryPolicy is set and the turn completes
+// with StopFailed because the app-server marked the failure retryable
+// (turn/completed's error.willRetry), it restarts the turn with the same
+// inputs, running it up to RetryPolicy.MaxAttempts times in total, before
+// giving up and returning the last attempt's result; TurnResult.RetryCount
+// reports how many restarts that took. A non-retryable failure (returned as an error,
+// typically a *TurnError) passes through on the first attempt without
+// retrying, since RetryPolicy only governs turns the server itself
+// flagged as worth retrying.
+func (t *Thread) RunWithOptions(ctx context.Context, opts TurnOptions, inputs ...Input) (*TurnResult, error) {
+	attempts := opts.RetryPolicy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var result *TurnResult
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			resolveLogger(t.logger).Debug("retrying failed turn", "attempt", attempt, "maxAttempts", attempts)
+			if err := sleepForRetry(ctx, opts.RetryPolicy); err != nil {
+				return result, err
+			}
+		}
+
+		stream, err := t.RunStreamed(ctx, inputs, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, err = stream.Wait(ctx)
+		if err != nil {
+			return result, err
+		}
+		result.RetryCount = attempt
+		if result.StopReason != StopFailed {
+			if opts.RequireFinalResponse && !result.HasFinalResponse() {
+				return result, ErrNoFinalResponse
+			}
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// sleepForRetry waits RetryPolicy.BaseDelay plus a random jitter in
+// [0, RetryPolicy.Jitter) before the next retry attempt, returning early
+// with ctx.Err() if ctx is done first.
+func sleepForRetry(ctx context.Context, policy RetryPolicy) error {
+	delay := policy.BaseDelay
+	if policy.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(policy.Jitter)))
+	}
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SendMessage injects a steering message into this thread's in-progress
+// turn without starting a fresh awaited turn, returning once the
+// app-server acknowledges receipt via turn/addMessage. Use it to add
+// guidance to a turn already running under RunStreamed (for example,
+// "also check the tests") without waiting for it to complete first, or
+// interrupting it the way CloseAndInterrupt does. If no turn is in
+// progress for this thread, the app-server rejects the request and
+// SendMessage returns its error; there's no RPC to start a turn this way,
+// so callers with no turn running yet should call Run/RunStreamed
+// instead.
+func (t *Thread) SendMessage(ctx context.Context, text string) error {
+	if err := t.checkNotDeleted(); err != nil {
+		return err
+	}
+	params := map[string]any{
+		"threadId": t.id,
+		"input":    []map[string]any{{"type": "text", "text": text}},
+	}
+	if err := t.codex.currentClient().Call("turn/addMessage", params, nil); err != nil {
+		return fmt.Errorf("codex: turn/addMessage: %w", err)
+	}
+	return nil
+}
+
+// HistoryItem is one entry in a thread's conversation transcript, as
+// returned by Thread.History.
+type HistoryItem struct {
+	// Type is the item's kind, as reported by the app-server (for example
+	// "user_message", "agent_message", or "mcp_tool_call").
+	Type string
+	// Text is the item's text, as returned by extractText. It's empty for
+	// item types that carry no plain text (for example a tool call with
+	// only structured arguments).
+	Text string
+	// Raw is the item's full decoded payload, for fields History doesn't
+	// surface as a typed field.
+	Raw map[string]any
+}
+
+// History fetches this thread's full conversation transcript from the
+// app-server via thread/history, decoding each entry into a HistoryItem.
+// Unlike Items, which only holds whatever history a resumed thread
+// happened to carry in memory, History always reflects what the
+// app-server has on file, so it can be persisted and used to resume the
+// thread later without the app-server being the only source of truth.
+func (t *Thread) History(ctx context.Context) ([]HistoryItem, error) {
+	if err := t.checkNotDeleted(); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := t.codex.currentClient().Call("thread/history", map[string]any{"threadId": t.id}, &resp); err != nil {
+		return nil, fmt.Errorf("codex: thread/history: %w", err)
+	}
+	items := make([]HistoryItem, len(resp.Items))
+	for i, item := range resp.Items {
+		itemType, _ := item["type"].(string)
+		items[i] = HistoryItem{
+			Type: itemType,
+			Text: extractText(item),
+			Raw:  item,
+		}
+	}
+	return items, nil
+}
+
+// Compact asks the app-server to summarize and shrink this thread's
+// history, then blocks until it acknowledges the compaction finished. The
+// thread id is unchanged, so subsequent Run calls continue seamlessly on
+// the same Thread. Use TurnOptions.AutoCompact instead if compaction
+// should happen automatically whenever a turn is about to exceed the
+// model's context window.
+func (t *Thread) Compact(ctx context.Context) error {
+	if err := t.checkNotDeleted(); err != nil {
+		return err
+	}
+	if err := t.codex.currentClient().Call("thread/compact", map[string]any{"threadId": t.id}, nil); err != nil {
+		return fmt.Errorf("codex: thread/compact: %w", err)
+	}
+	return nil
+}
+
+// Delete asks the app-server to remove this thread's server-side state
+// (its rollout and any in-memory conversation it's holding), for apps that
+// create many ephemeral threads and want to avoid unbounded growth on the
+// server. Once Delete succeeds, every other method on t returns
+// ErrThreadDeleted; t itself remains safe to hold onto (for example to log
+// its ID), it just can no longer be used to talk to the app-server.
+func (t *Thread) Delete(ctx context.Context) error {
+	if err := t.checkNotDeleted(); err != nil {
+		return err
+	}
+	if err := t.codex.currentClient().Call("thread/delete", map[string]any{"threadId": t.id}, nil); err != nil {
+		return fmt.Errorf("codex: thread/delete: %w", err)
+	}
+	t.mu.Lock()
+	t.deleted = true
+	t.mu.Unlock()
+	return nil
+}
+
+// PolicyUpdate overrides a live thread's approval and sandbox policy, for
+// Thread.UpdatePolicy. A zero field leaves that policy unchanged.
+type PolicyUpdate struct {
+	// ApprovalPolicy overrides when the agent pauses to ask for approval.
+	ApprovalPolicy ApprovalPolicy
+	// Sandbox overrides the execution sandbox applied to commands the
+	// agent runs.
+	Sandbox SandboxMode
+}
+
+// UpdatePolicy overrides this thread's approval and/or sandbox policy via
+// thread/settings/update, for example to tighten the sandbox after initial
+// exploration with a looser one. The app-server applies the override to
+// the thread's stored settings immediately, but the wire protocol doesn't
+// document whether a turn already in progress picks it up mid-flight or
+// only the next one started after this call returns; callers that need a
+// hard guarantee should assume the latter and avoid relying on this to
+// affect a turn already running.
+func (t *Thread) UpdatePolicy(ctx context.Context, update PolicyUpdate) error {
+	if err := t.checkNotDeleted(); err != nil {
+		return err
+	}
+	params := map[string]any{"threadId": t.id}
+	if update.ApprovalPolicy != "" {
+		params["approvalPolicy"] = string(update.ApprovalPolicy)
+	}
+	if update.Sandbox != "" {
+		params["sandboxPolicy"] = map[string]any{"type": string(update.Sandbox)}
+	}
+	if err := t.codex.currentClient().Call("thread/settings/update", params, nil); err != nil {
+		return fmt.Errorf("codex: thread/settings/update: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that opts would produce a well-formed thread/start (or
+// thread/resume) request without contacting the app-server: known enum
+// values and MCPServers entries with a name, a command, and no name reused
+// across two entries (the app-server keys them by name, so a duplicate
+// would silently shadow the first). It supports linting a saved
+// configuration in CI before it's ever used to start a thread.
+func (opts ThreadStartOptions) Validate() error {
+	if opts.ApprovalPolicy != "" && !knownApprovalPolicies[opts.ApprovalPolicy] {
+		return &EnumValidationError{Field: "ApprovalPolicy", Value: string(opts.ApprovalPolicy)}
+	}
+	seen := make(map[string]bool, len(opts.MCPServers))
+	for _, s := range opts.MCPServers {
+		if s.Name == "" {
+			return fmt.Errorf("codex: ThreadStartOptions.Validate: MCP server missing Name")
+		}
+		if s.Command == "" {
+			return fmt.Errorf("codex: ThreadStartOptions.Validate: MCP server %q missing Command", s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("codex: ThreadStartOptions.Validate: MCP server name %q used more than once", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+func toParams(opts ThreadStartOptions) map[string]any {
+	params := map[string]any{}
+	if opts.Model != "" {
+		params["model"] = opts.Model
+	}
+	if opts.WorkingDirectory != "" {
+		params["cwd"] = opts.WorkingDirectory
+	}
+	if len(opts.MCPServers) > 0 {
+		params["mcpServers"] = mcpServersToParams(opts.MCPServers)
+	}
+	if opts.ApprovalPolicy != "" {
+		params["approvalPolicy"] = string(opts.ApprovalPolicy)
+	}
+	for k, v := range opts.Config {
+		params[k] = v
+	}
+	return params
+}
+
+// mcpServersToParams serializes MCPServerConfig entries into the nested
+// config payload the app-server expects: an object keyed by server name.
+func mcpServersToParams(servers []MCPServerConfig) map[string]any {
+	out := make(map[string]any, len(servers))
+	for _, s := range servers {
+		entry := map[string]any{"command": s.Command}
+		if len(s.Args) > 0 {
+			entry["args"] = s.Args
+		}
+		if len(s.Env) > 0 {
+			entry["env"] = s.Env
+		}
+		if s.TransportType != "" {
+			entry["transportType"] = string(s.TransportType)
+		}
+		out[s.Name] = entry
+	}
+	return out
+}
+
+// rolloutThreadIDPattern matches the thread id embedded in a rollout
+// filename of the form rollout-<timestamp>-<thread id>.jsonl. Thread ids
+// are UUIDv7, so this looks for the standard UUID shape rather than
+// trying to split on the filename's own dashes, which the timestamp
+// component also contains.
+var rolloutThreadIDPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// ResumeThreadFromPath resumes a thread directly from its on-disk rollout
+// file, the common "reopen my last session" case where a caller has a
+// rollout path (for example, the most recently modified file under
+// CODEX_HOME/sessions) but not the thread id it belongs to. It extracts
+// the id embedded in path's filename and resumes exactly as ResumeThread
+// would with that id and opts.Path set to path.
+//
+// It returns an error wrapping ErrMalformedRolloutPath if path's filename
+// doesn't embed a recognizable thread id, or the underlying *os.PathError
+// (checkable with errors.Is(err, os.ErrNotExist)) if path doesn't exist.
+func (c *Codex) ResumeThreadFromPath(ctx context.Context, path string, opts ThreadResumeOptions) (*Thread, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("codex: ResumeThreadFromPath: %w", err)
+	}
+	id := rolloutThreadIDPattern.FindString(filepath.Base(path))
+	if id == "" {
+		return nil, fmt.Errorf("codex: ResumeThreadFromPath: %w: %s", ErrMalformedRolloutPath, filepath.Base(path))
+	}
+	opts.Path = path
+	return c.ResumeThread(ctx, id, opts)
+}
+
+func toResumeParams(id string, opts ThreadResumeOptions) map[string]any {
+	params := map[string]any{}
+	if id != "" {
+		params["threadId"] = id
+	}
+	if opts.Path != "" {
+		params["path"] = opts.Path
+	}
+	if opts.History != nil {
+		params["history"] = applyHistoryLimit(opts.History, opts.HistoryLimit)
+	}
+	return params
+}
+
+// applyHistoryLimit returns the last limit items of history, or history
+// unchanged if limit is <= 0 or doesn't shorten it.
+func applyHistoryLimit(history []any, limit int) []any {
+	if limit <= 0 || len(history) <= limit {
+		return history
+	}
+	return history[len(history)-limit:]
+}
@@ -0,0 +1,650 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestApplyHistoryLimit(t *testing.T) {
+	history := []any{"a", "b", "c", "d", "e"}
+
+	if got := applyHistoryLimit(history, 0); len(got) != 5 {
+		t.Fatalf("limit 0: len = %d, want 5", len(got))
+	}
+	if got := applyHistoryLimit(history, 10); len(got) != 5 {
+		t.Fatalf("limit > len: len = %d, want 5", len(got))
+	}
+
+	got := applyHistoryLimit(history, 2)
+	want := []any{"d", "e"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("limit 2: got %v, want %v", got, want)
+	}
+}
+
+func TestToParamsSerializesMCPServers(t *testing.T) {
+	params := toParams(ThreadStartOptions{
+		MCPServers: []MCPServerConfig{
+			{
+				Name:    "fs",
+				Command: "mcp-server-fs",
+				Args:    []string{"--root", "/work"},
+				Env:     map[string]string{"LOG_LEVEL": "debug"},
+			},
+			{
+				Name:          "search",
+				Command:       "https://search.example/mcp",
+				TransportType: MCPTransportHTTP,
+			},
+		},
+	})
+
+	servers, ok := params["mcpServers"].(map[string]any)
+	if !ok {
+		t.Fatalf("params[\"mcpServers\"] = %T, want map[string]any", params["mcpServers"])
+	}
+
+	fs, ok := servers["fs"].(map[string]any)
+	if !ok {
+		t.Fatalf("servers[\"fs\"] = %T, want map[string]any", servers["fs"])
+	}
+	if fs["command"] != "mcp-server-fs" {
+		t.Fatalf("fs command = %v, want mcp-server-fs", fs["command"])
+	}
+	if args, ok := fs["args"].([]string); !ok || len(args) != 2 || args[0] != "--root" {
+		t.Fatalf("fs args = %v, want [--root /work]", fs["args"])
+	}
+	if _, hasTransport := fs["transportType"]; hasTransport {
+		t.Fatal("fs transportType should be omitted when empty (defaults to stdio)")
+	}
+
+	search, ok := servers["search"].(map[string]any)
+	if !ok {
+		t.Fatalf("servers[\"search\"] = %T, want map[string]any", servers["search"])
+	}
+	if search["transportType"] != "http" {
+		t.Fatalf("search transportType = %v, want http", search["transportType"])
+	}
+	if _, hasArgs := search["args"]; hasArgs {
+		t.Fatal("search args should be omitted when empty")
+	}
+}
+
+func TestToParamsSerializesApprovalPolicy(t *testing.T) {
+	params := toParams(ThreadStartOptions{ApprovalPolicy: ApprovalPolicyNever})
+	if params["approvalPolicy"] != "never" {
+		t.Fatalf("params[approvalPolicy] = %v, want never", params["approvalPolicy"])
+	}
+}
+
+func TestThreadStartOptionsValidateRejectsUnknownApprovalPolicy(t *testing.T) {
+	opts := ThreadStartOptions{ApprovalPolicy: ApprovalPolicy("not-a-real-policy")}
+	var enumErr *EnumValidationError
+	if err := opts.Validate(); !errors.As(err, &enumErr) {
+		t.Fatalf("Validate() = %v, want an *EnumValidationError", err)
+	}
+}
+
+func TestThreadStartOptionsValidateRejectsDuplicateMCPServerNames(t *testing.T) {
+	opts := ThreadStartOptions{
+		MCPServers: []MCPServerConfig{
+			{Name: "search", Command: "search-server"},
+			{Name: "search", Command: "other-search-server"},
+		},
+	}
+	if err := opts.Validate(); err == nil {
+		t.Fatal("Validate() = nil, want an error for the duplicate server name")
+	}
+}
+
+func TestThreadStartOptionsValidateRejectsMCPServerMissingCommand(t *testing.T) {
+	opts := ThreadStartOptions{MCPServers: []MCPServerConfig{{Name: "search"}}}
+	if err := opts.Validate(); err == nil {
+		t.Fatal("Validate() = nil, want an error for the missing Command")
+	}
+}
+
+func TestThreadStartOptionsValidateAcceptsWellFormedOptions(t *testing.T) {
+	opts := ThreadStartOptions{
+		ApprovalPolicy: ApprovalPolicyOnRequest,
+		MCPServers:     []MCPServerConfig{{Name: "search", Command: "search-server"}},
+	}
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestSendMessageCallsTurnAddMessageWithThreadAndText(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+
+	if err := thread.SendMessage(context.Background(), "also check the tests"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	var req struct {
+		Method string `json:"method"`
+		Params struct {
+			ThreadID string `json:"threadId"`
+			Input    []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"input"`
+		} `json:"params"`
+	}
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Method != "turn/addMessage" {
+		t.Fatalf("Method = %q, want turn/addMessage", req.Method)
+	}
+	if req.Params.ThreadID != "thread-1" {
+		t.Fatalf("ThreadID = %q, want thread-1", req.Params.ThreadID)
+	}
+	if len(req.Params.Input) != 1 || req.Params.Input[0].Text != "also check the tests" {
+		t.Fatalf("Input = %+v, want a single text item", req.Params.Input)
+	}
+}
+
+func TestRunWithOptionsRequireFinalResponseErrorsWhenTurnHasNoAssistantMessage(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "command_execution", "command": "ls"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	result, err := thread.RunWithOptions(context.Background(), TurnOptions{RequireFinalResponse: true}, TextInput("go"))
+	if !errors.Is(err, ErrNoFinalResponse) {
+		t.Fatalf("err = %v, want ErrNoFinalResponse", err)
+	}
+	if result == nil || result.HasFinalResponse() {
+		t.Fatalf("result = %+v, want a non-nil result with no final response", result)
+	}
+}
+
+func TestRunWithOptionsRequireFinalResponseAcceptsAssistantMessage(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	result, err := thread.RunWithOptions(context.Background(), TurnOptions{RequireFinalResponse: true}, TextInput("go"))
+	if err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	if !result.HasFinalResponse() || result.FinalResponse != "done" {
+		t.Fatalf("result = %+v, want FinalResponse = done", result)
+	}
+}
+
+func TestRunWithCallbackInvokesCallbackForEachNotification(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "hi"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	var methods []string
+	result, err := thread.RunWithCallback(context.Background(), []Input{TextInput("go")}, TurnOptions{}, func(note rpc.Notification) error {
+		methods = append(methods, note.Method)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunWithCallback: %v", err)
+	}
+	if result.StopReason != StopCompleted {
+		t.Fatalf("StopReason = %q, want %q", result.StopReason, StopCompleted)
+	}
+	if len(methods) != 2 || methods[0] != "item/completed" || methods[1] != "turn/completed" {
+		t.Fatalf("methods = %v, want [item/completed turn/completed]", methods)
+	}
+}
+
+func TestRunWithCallbackStopsEarlyOnCallbackError(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "hi"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	wantErr := errors.New("stop here")
+	_, err := thread.RunWithCallback(context.Background(), []Input{TextInput("go")}, TurnOptions{}, func(note rpc.Notification) error {
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("RunWithCallback err = %v, want %v", err, wantErr)
+	}
+}
+
+func TestHistoryDecodesTranscriptItems(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{"items":[
+		{"type":"user_message","text":"hi"},
+		{"type":"agent_message","text":"hello"},
+		{"type":"mcp_tool_call","server":"fs","tool":"read_file"}
+	]}}`)
+
+	items, err := thread.History(context.Background())
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("len(items) = %d, want 3", len(items))
+	}
+	if items[0].Type != "user_message" || items[0].Text != "hi" {
+		t.Fatalf("items[0] = %+v, want Type user_message, Text hi", items[0])
+	}
+	if items[1].Type != "agent_message" || items[1].Text != "hello" {
+		t.Fatalf("items[1] = %+v, want Type agent_message, Text hello", items[1])
+	}
+	if items[2].Type != "mcp_tool_call" || items[2].Raw["server"] != "fs" {
+		t.Fatalf("items[2] = %+v, want Type mcp_tool_call, Raw.server fs", items[2])
+	}
+}
+
+func TestRunWithOptionsRetriesRetryableFailureAndReportsRetryCount(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{CallTimeout: time.Second})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.onWrite = func(n int, line string) {
+		switch n {
+		case 1:
+			transport.push(`{"id":1,"result":{}}`)
+			transport.push(notificationLine(t, "turn/completed", map[string]any{
+				"threadId": "thread-1",
+				"turn": map[string]any{
+					"status": "failed",
+					"error":  map[string]any{"message": "rate limited", "code": 429, "willRetry": true},
+				},
+			}))
+		case 2:
+			transport.push(`{"id":2,"result":{}}`)
+			transport.push(notificationLine(t, "item/completed", map[string]any{
+				"threadId": "thread-1",
+				"turnId":   "turn-2",
+				"item":     map[string]any{"type": "agent_message", "text": "done"},
+			}))
+			transport.push(notificationLine(t, "turn/completed", map[string]any{
+				"threadId": "thread-1",
+				"turn":     map[string]any{"status": "completed"},
+			}))
+		}
+	}
+
+	result, err := thread.RunWithOptions(context.Background(), TurnOptions{
+		RetryPolicy: RetryPolicy{MaxAttempts: 2},
+	}, TextInput("go"))
+	if err != nil {
+		t.Fatalf("RunWithOptions: %v", err)
+	}
+	if result.StopReason != StopCompleted {
+		t.Fatalf("StopReason = %q, want %q", result.StopReason, StopCompleted)
+	}
+	if result.RetryCount != 1 {
+		t.Fatalf("RetryCount = %d, want 1", result.RetryCount)
+	}
+	if result.FinalResponse != "done" {
+		t.Fatalf("FinalResponse = %q, want done", result.FinalResponse)
+	}
+}
+
+func TestRunWithOptionsDoesNotRetryNonRetryableFailure(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn": map[string]any{
+			"status": "failed",
+			"error":  map[string]any{"message": "bad request", "code": 400},
+		},
+	}))
+
+	_, err := thread.RunWithOptions(context.Background(), TurnOptions{
+		RetryPolicy: RetryPolicy{MaxAttempts: 3},
+	}, TextInput("go"))
+	var turnErr *TurnError
+	if !errors.As(err, &turnErr) {
+		t.Fatalf("RunWithOptions err = %v, want *TurnError", err)
+	}
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one turn/start request (no retry)", transport.written)
+	}
+}
+
+func TestSendMessageWrapsAppServerError(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"error":{"code":-32000,"message":"no turn in progress"}}`)
+
+	err := thread.SendMessage(context.Background(), "also check the tests")
+	var rpcErr *rpc.RPCError
+	if err == nil {
+		t.Fatal("SendMessage: want an error when no turn is in progress")
+	}
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("SendMessage err = %v, want *rpc.RPCError", err)
+	}
+}
+
+func TestCompactCallsThreadCompactWithThreadID(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+
+	if err := thread.Compact(context.Background()); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+
+	var req struct {
+		Method string `json:"method"`
+		Params struct {
+			ThreadID string `json:"threadId"`
+		} `json:"params"`
+	}
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Method != "thread/compact" {
+		t.Fatalf("Method = %q, want thread/compact", req.Method)
+	}
+	if req.Params.ThreadID != "thread-1" {
+		t.Fatalf("ThreadID = %q, want thread-1", req.Params.ThreadID)
+	}
+}
+
+func TestCompactWrapsAppServerError(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"error":{"code":-32000,"message":"nothing to compact"}}`)
+
+	err := thread.Compact(context.Background())
+	var rpcErr *rpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Compact err = %v, want *rpc.RPCError", err)
+	}
+}
+
+func TestDeleteCallsThreadDeleteWithThreadID(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+
+	if err := thread.Delete(context.Background()); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	var req struct {
+		Method string `json:"method"`
+		Params struct {
+			ThreadID string `json:"threadId"`
+		} `json:"params"`
+	}
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Method != "thread/delete" {
+		t.Fatalf("Method = %q, want thread/delete", req.Method)
+	}
+	if req.Params.ThreadID != "thread-1" {
+		t.Fatalf("ThreadID = %q, want thread-1", req.Params.ThreadID)
+	}
+}
+
+func TestDeletedThreadRejectsFurtherCalls(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	if err := thread.Delete(context.Background()); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if err := thread.Delete(context.Background()); !errors.Is(err, ErrThreadDeleted) {
+		t.Fatalf("second Delete err = %v, want ErrThreadDeleted", err)
+	}
+	if err := thread.Compact(context.Background()); !errors.Is(err, ErrThreadDeleted) {
+		t.Fatalf("Compact err = %v, want ErrThreadDeleted", err)
+	}
+	if _, err := thread.History(context.Background()); !errors.Is(err, ErrThreadDeleted) {
+		t.Fatalf("History err = %v, want ErrThreadDeleted", err)
+	}
+	if err := thread.SendMessage(context.Background(), "hi"); !errors.Is(err, ErrThreadDeleted) {
+		t.Fatalf("SendMessage err = %v, want ErrThreadDeleted", err)
+	}
+	if _, err := thread.RunStreamed(context.Background(), nil, TurnOptions{}); !errors.Is(err, ErrThreadDeleted) {
+		t.Fatalf("RunStreamed err = %v, want ErrThreadDeleted", err)
+	}
+}
+
+func TestUpdatePolicySendsOnlySetFields(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+
+	err := thread.UpdatePolicy(context.Background(), PolicyUpdate{Sandbox: SandboxModeReadOnly})
+	if err != nil {
+		t.Fatalf("UpdatePolicy: %v", err)
+	}
+
+	var req struct {
+		Method string `json:"method"`
+		Params struct {
+			ThreadID       string `json:"threadId"`
+			ApprovalPolicy string `json:"approvalPolicy"`
+			SandboxPolicy  *struct {
+				Type string `json:"type"`
+			} `json:"sandboxPolicy"`
+		} `json:"params"`
+	}
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req.Method != "thread/settings/update" {
+		t.Fatalf("Method = %q, want thread/settings/update", req.Method)
+	}
+	if req.Params.ThreadID != "thread-1" {
+		t.Fatalf("ThreadID = %q, want thread-1", req.Params.ThreadID)
+	}
+	if req.Params.ApprovalPolicy != "" {
+		t.Fatalf("ApprovalPolicy = %q, want empty (not set)", req.Params.ApprovalPolicy)
+	}
+	if req.Params.SandboxPolicy == nil || req.Params.SandboxPolicy.Type != "read-only" {
+		t.Fatalf("SandboxPolicy = %+v, want type read-only", req.Params.SandboxPolicy)
+	}
+}
+
+func TestUpdatePolicyWrapsAppServerError(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"error":{"code":-32000,"message":"unknown policy"}}`)
+
+	err := thread.UpdatePolicy(context.Background(), PolicyUpdate{ApprovalPolicy: ApprovalPolicyNever})
+	var rpcErr *rpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("UpdatePolicy err = %v, want *rpc.RPCError", err)
+	}
+}
+
+func TestToResumeParamsAppliesHistoryLimit(t *testing.T) {
+	params := toResumeParams("thread-1", ThreadResumeOptions{
+		History:      []any{"a", "b", "c"},
+		HistoryLimit: 1,
+	})
+	history, ok := params["history"].([]any)
+	if !ok || len(history) != 1 || history[0] != "c" {
+		t.Fatalf("history = %v, want last 1 item", params["history"])
+	}
+}
+
+func TestResumeThreadFromPathExtractsThreadIDFromFilename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rollout-2024-01-01T10-00-00-9f1c9e3e-9f2d-4b1a-8e7f-1c2d3e4f5a6b.jsonl")
+	if err := os.WriteFile(path, []byte(""), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+	c := &Codex{client: client}
+
+	transport.push(`{"id":1,"result":{"thread":{"id":"9f1c9e3e-9f2d-4b1a-8e7f-1c2d3e4f5a6b","model":"gpt-5-codex","cwd":"/work"}}}`)
+
+	thread, err := c.ResumeThreadFromPath(context.Background(), path, ThreadResumeOptions{})
+	if err != nil {
+		t.Fatalf("ResumeThreadFromPath: %v", err)
+	}
+	if thread.ID() != "9f1c9e3e-9f2d-4b1a-8e7f-1c2d3e4f5a6b" {
+		t.Fatalf("ID() = %q, want the uuid embedded in the filename", thread.ID())
+	}
+}
+
+func TestResumeThreadFromPathMissingFile(t *testing.T) {
+	c := &Codex{client: rpc.NewClient(newFakeTransport(), rpc.ClientOptions{})}
+	defer c.client.Close()
+
+	_, err := c.ResumeThreadFromPath(context.Background(), filepath.Join(t.TempDir(), "missing.jsonl"), ThreadResumeOptions{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestResumeThreadFromPathMalformedFilename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "not-a-rollout-file.jsonl")
+	if err := os.WriteFile(path, []byte(""), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	c := &Codex{client: rpc.NewClient(newFakeTransport(), rpc.ClientOptions{})}
+	defer c.client.Close()
+
+	_, err := c.ResumeThreadFromPath(context.Background(), path, ThreadResumeOptions{})
+	if !errors.Is(err, ErrMalformedRolloutPath) {
+		t.Fatalf("err = %v, want ErrMalformedRolloutPath", err)
+	}
+}
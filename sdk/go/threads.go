@@ -0,0 +1,100 @@
+package codex
+
+import (
+	"context"
+	"fmt"
+)
+
+// ThreadSummary describes one thread as reported by thread/list, the
+// lightweight metadata the app-server tracks for every thread it knows
+// about (as opposed to the fuller Thread returned by thread/start and
+// thread/resume, which also carries loaded conversation items).
+//
+// thread/list doesn't report a specific model name the way thread/start
+// and thread/resume do, only the model provider the thread was created
+// under (for example, "openai"), so ModelProvider is what's actually on
+// the wire here rather than a Model field.
+type ThreadSummary struct {
+	// ID is the thread's id.
+	ID string
+	// ModelProvider is the model provider the thread was created under.
+	ModelProvider string
+	// WorkingDirectory is the working directory captured for the thread.
+	WorkingDirectory string
+	// Preview is usually the first user message in the thread, if available.
+	Preview string
+	// Status is the thread's current runtime status, as reported by the
+	// app-server (for example, "active" or "idle").
+	Status string
+	// CreatedAt is the Unix timestamp, in seconds, the thread was created.
+	CreatedAt int64
+	// UpdatedAt is the Unix timestamp, in seconds, the thread was last
+	// updated.
+	UpdatedAt int64
+	// RecencyAt is the Unix timestamp, in seconds, the app-server uses for
+	// recency ordering, the closest wire equivalent to "last activity", or
+	// nil if the app-server didn't report one.
+	RecencyAt *int64
+}
+
+// threadListResponse mirrors thread/list's response shape: a page of
+// threads plus an opaque cursor for the next page.
+type threadListResponse struct {
+	Data []struct {
+		ID            string `json:"id"`
+		ModelProvider string `json:"modelProvider"`
+		Cwd           string `json:"cwd"`
+		Preview       string `json:"preview"`
+		Status        string `json:"status"`
+		CreatedAt     int64  `json:"createdAt"`
+		UpdatedAt     int64  `json:"updatedAt"`
+		RecencyAt     *int64 `json:"recencyAt"`
+	} `json:"data"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// ListThreads enumerates every thread the app-server currently knows
+// about, such as after reconnecting or attaching to a shared daemon, so a
+// caller can build multi-session UIs or close idle threads. thread/list is
+// paginated on the wire; ListThreads follows the returned cursor until the
+// app-server reports no more pages, so callers get the full list in one
+// call. It returns an empty, non-nil slice (not an error) when there are
+// no threads.
+func (c *Codex) ListThreads(ctx context.Context) ([]ThreadSummary, error) {
+	var summaries []ThreadSummary
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var resp threadListResponse
+		if err := c.currentClient().Call("thread/list", params, &resp); err != nil {
+			return nil, fmt.Errorf("codex: thread/list: %w", err)
+		}
+
+		for _, t := range resp.Data {
+			summaries = append(summaries, ThreadSummary{
+				ID:               t.ID,
+				ModelProvider:    t.ModelProvider,
+				WorkingDirectory: t.Cwd,
+				Preview:          t.Preview,
+				Status:           t.Status,
+				CreatedAt:        t.CreatedAt,
+				UpdatedAt:        t.UpdatedAt,
+				RecencyAt:        t.RecencyAt,
+			})
+		}
+
+		if resp.NextCursor == nil || *resp.NextCursor == "" {
+			break
+		}
+		cursor = *resp.NextCursor
+	}
+
+	if summaries == nil {
+		summaries = []ThreadSummary{}
+	}
+	return summaries, nil
+}
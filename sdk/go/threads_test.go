@@ -0,0 +1,91 @@
+package codex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestListThreadsDecodesSummaries(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.push(`{"id":1,"result":{"data":[
+		{"id":"thread-1","modelProvider":"openai","cwd":"/work","preview":"fix the bug","status":"idle","createdAt":1700000000,"updatedAt":1700000100,"recencyAt":1700000100},
+		{"id":"thread-2","modelProvider":"openai","cwd":"/other","preview":"add a feature","status":"active","createdAt":1700000200,"updatedAt":1700000300}
+	],"nextCursor":null}}`)
+
+	summaries, err := c.ListThreads(context.Background())
+	if err != nil {
+		t.Fatalf("ListThreads: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("len(summaries) = %d, want 2", len(summaries))
+	}
+	if summaries[0].ID != "thread-1" || summaries[0].Status != "idle" {
+		t.Fatalf("summaries[0] = %+v", summaries[0])
+	}
+	if summaries[0].RecencyAt == nil || *summaries[0].RecencyAt != 1700000100 {
+		t.Fatalf("summaries[0].RecencyAt = %v, want 1700000100", summaries[0].RecencyAt)
+	}
+	if summaries[1].RecencyAt != nil {
+		t.Fatalf("summaries[1].RecencyAt = %v, want nil", summaries[1].RecencyAt)
+	}
+}
+
+func TestListThreadsFollowsCursorUntilExhausted(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{CallTimeout: time.Second})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.onWrite = func(n int, line string) {
+		switch n {
+		case 1:
+			transport.push(`{"id":1,"result":{"data":[{"id":"thread-1","createdAt":1,"updatedAt":1}],"nextCursor":"page-2"}}`)
+		case 2:
+			transport.push(`{"id":2,"result":{"data":[{"id":"thread-2","createdAt":2,"updatedAt":2}],"nextCursor":null}}`)
+		}
+	}
+
+	summaries, err := c.ListThreads(context.Background())
+	if err != nil {
+		t.Fatalf("ListThreads: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("len(summaries) = %d, want 2", len(summaries))
+	}
+	if summaries[0].ID != "thread-1" || summaries[1].ID != "thread-2" {
+		t.Fatalf("summaries = %+v, want thread-1 then thread-2", summaries)
+	}
+	if len(transport.written) != 2 {
+		t.Fatalf("written = %d requests, want 2 pages fetched", len(transport.written))
+	}
+}
+
+func TestListThreadsReturnsEmptySliceNotNil(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client}
+
+	transport.push(`{"id":1,"result":{"data":[],"nextCursor":null}}`)
+
+	summaries, err := c.ListThreads(context.Background())
+	if err != nil {
+		t.Fatalf("ListThreads: %v", err)
+	}
+	if summaries == nil {
+		t.Fatal("summaries = nil, want an empty non-nil slice")
+	}
+	if len(summaries) != 0 {
+		t.Fatalf("len(summaries) = %d, want 0", len(summaries))
+	}
+}
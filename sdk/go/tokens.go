@@ -0,0 +1,43 @@
+package codex
+
+// charsPerToken approximates the number of characters per token for
+// English-like text, matching the rule of thumb OpenAI's own docs use
+// ("roughly 4 characters per token"). It's deliberately simple: a real
+// tokenizer is model-specific and not worth vendoring just to let callers
+// pre-flight a turn.
+const charsPerToken = 4
+
+// estimatedImageTokens is the fixed per-image estimate used by
+// EstimateTokens, regardless of resolution. It's in the ballpark of a
+// low-detail image under typical vision tokenizers; callers needing
+// precision for a specific model should consult that model's docs instead.
+const estimatedImageTokens = 85
+
+// EstimateTokens approximates the number of tokens inputs will consume,
+// so a caller can pre-flight a turn and warn or compact before hitting a
+// context limit. It is not exact: text is estimated at roughly
+// charsPerToken characters per token, and every image-typed input (Image,
+// LocalImage) counts as a fixed estimatedImageTokens regardless of its
+// actual resolution. Skill inputs are estimated from their name's length.
+func EstimateTokens(inputs []Input) (int, error) {
+	var total int
+	for _, input := range inputs {
+		switch input.Type {
+		case "image", "localImage":
+			total += estimatedImageTokens
+		default:
+			total += estimateTextTokens(input.Text)
+		}
+	}
+	return total, nil
+}
+
+// estimateTextTokens approximates the token count of a string using the
+// charsPerToken heuristic, rounding up so non-empty text never estimates
+// to zero tokens.
+func estimateTextTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
@@ -0,0 +1,33 @@
+package codex
+
+import "testing"
+
+func TestEstimateTokensApproximatesTextByLength(t *testing.T) {
+	got, err := EstimateTokens([]Input{TextInput("12345678")}) // 8 chars
+	if err != nil {
+		t.Fatalf("EstimateTokens: %v", err)
+	}
+	if got != 2 {
+		t.Fatalf("EstimateTokens = %d, want 2 (8 chars / 4 per token)", got)
+	}
+}
+
+func TestEstimateTokensUsesFixedCostPerImage(t *testing.T) {
+	got, err := EstimateTokens([]Input{ImageInput("https://example.com/a.png"), LocalImageInput("/tmp/b.png")})
+	if err != nil {
+		t.Fatalf("EstimateTokens: %v", err)
+	}
+	if got != 2*estimatedImageTokens {
+		t.Fatalf("EstimateTokens = %d, want %d", got, 2*estimatedImageTokens)
+	}
+}
+
+func TestEstimateTokensSumsMixedInputs(t *testing.T) {
+	got, err := EstimateTokens([]Input{TextInput("1234"), ImageInput("https://example.com/a.png")})
+	if err != nil {
+		t.Fatalf("EstimateTokens: %v", err)
+	}
+	if got != 1+estimatedImageTokens {
+		t.Fatalf("EstimateTokens = %d, want %d", got, 1+estimatedImageTokens)
+	}
+}
@@ -0,0 +1,80 @@
+package codex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+type recordingSpan struct {
+	attributes map[string]any
+	events     []string
+	ended      bool
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) { s.attributes[key] = value }
+func (s *recordingSpan) AddEvent(name string, _ map[string]any) {
+	s.events = append(s.events, name)
+}
+func (s *recordingSpan) End() { s.ended = true }
+
+type recordingTracer struct {
+	names []string
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, rpc.Span) {
+	span := &recordingSpan{attributes: map[string]any{}}
+	t.names = append(t.names, name)
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestRunRecordsTurnSpanWithUsageAndNotificationEvents(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	tracer := &recordingTracer{}
+	c := &Codex{client: client, tracer: tracer}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	transport.push(`{"id":1,"result":{}}`)
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn": map[string]any{
+			"status": "completed",
+			"usage":  map[string]any{"inputTokens": 10.0, "outputTokens": 5.0},
+		},
+	}))
+
+	if _, err := thread.Run(context.Background(), TextInput("go")); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("len(spans) = %d, want 1", len(tracer.spans))
+	}
+	if tracer.names[0] != "turn" {
+		t.Fatalf("span name = %q, want turn", tracer.names[0])
+	}
+	span := tracer.spans[0]
+	if !span.ended {
+		t.Fatal("turn span was not ended")
+	}
+	if span.attributes["codex.threadId"] != "thread-1" {
+		t.Fatalf("codex.threadId = %v, want thread-1", span.attributes["codex.threadId"])
+	}
+	if span.attributes["codex.usage.totalTokens"] != 15 {
+		t.Fatalf("codex.usage.totalTokens = %v, want 15", span.attributes["codex.usage.totalTokens"])
+	}
+	if len(span.events) != 2 || span.events[0] != "item/completed" || span.events[1] != "turn/completed" {
+		t.Fatalf("events = %v, want [item/completed turn/completed]", span.events)
+	}
+}
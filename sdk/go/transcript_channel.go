@@ -0,0 +1,57 @@
+package codex
+
+import (
+	"sync"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// transcriptChannelBuffer is the buffer size for channels returned by
+// TranscriptChannel. It only needs to absorb bursts; a sidecar that falls
+// behind drops entries rather than stalling the app-server read loop.
+const transcriptChannelBuffer = 256
+
+// transcriptBroadcaster fans out every line read from or written to the
+// app-server transport to live subscribers, independent of
+// rpc.RecordTransport's buffered record/replay use case.
+type transcriptBroadcaster struct {
+	mu   sync.Mutex
+	subs []chan rpc.TranscriptEntry
+}
+
+func (b *transcriptBroadcaster) onRead(line []byte) {
+	b.broadcast(rpc.TranscriptEntry{Direction: rpc.Received, Line: string(line)})
+}
+
+func (b *transcriptBroadcaster) onWrite(line []byte) {
+	b.broadcast(rpc.TranscriptEntry{Direction: rpc.Sent, Line: string(line)})
+}
+
+func (b *transcriptBroadcaster) broadcast(entry rpc.TranscriptEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- entry:
+		default:
+			// Slow subscriber: drop rather than block the RPC read loop.
+		}
+	}
+}
+
+func (b *transcriptBroadcaster) subscribe() <-chan rpc.TranscriptEntry {
+	ch := make(chan rpc.TranscriptEntry, transcriptChannelBuffer)
+	b.mu.Lock()
+	b.subs = append(b.subs, ch)
+	b.mu.Unlock()
+	return ch
+}
+
+// TranscriptChannel returns a channel that receives every line read from or
+// written to the app-server transport, live, for external log tooling such
+// as observability sidecars. Unlike rpc.RecordTransport, which buffers the
+// whole transcript for a later replay, entries are delivered as they
+// happen and are dropped, not queued, if the consumer falls behind.
+func (c *Codex) TranscriptChannel() <-chan rpc.TranscriptEntry {
+	return c.transcript.subscribe()
+}
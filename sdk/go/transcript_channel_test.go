@@ -0,0 +1,68 @@
+package codex
+
+import (
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestTranscriptBroadcasterDeliversReadsAndWrites(t *testing.T) {
+	b := &transcriptBroadcaster{}
+	ch := b.subscribe()
+
+	b.onRead([]byte(`{"method":"turn/progress"}`))
+	b.onWrite([]byte(`{"id":1,"method":"thread/start"}`))
+
+	first := <-ch
+	if first.Direction != rpc.Received || first.Line != `{"method":"turn/progress"}` {
+		t.Fatalf("first entry = %+v, want a Received entry", first)
+	}
+	second := <-ch
+	if second.Direction != rpc.Sent || second.Line != `{"id":1,"method":"thread/start"}` {
+		t.Fatalf("second entry = %+v, want a Sent entry", second)
+	}
+}
+
+func TestTranscriptBroadcasterDropsWhenSubscriberFalledBehind(t *testing.T) {
+	b := &transcriptBroadcaster{}
+	ch := b.subscribe()
+
+	for i := 0; i < transcriptChannelBuffer+10; i++ {
+		b.onRead([]byte("line"))
+	}
+
+	if len(ch) != transcriptChannelBuffer {
+		t.Fatalf("len(ch) = %d, want the channel full at %d rather than blocking", len(ch), transcriptChannelBuffer)
+	}
+}
+
+func TestTranscriptChannelObservesClientTraffic(t *testing.T) {
+	base := newFakeTransport()
+	broadcaster := &transcriptBroadcaster{}
+	tapped := rpc.NewTapTransport(base, broadcaster.onRead, broadcaster.onWrite)
+
+	client := rpc.NewClient(tapped, rpc.ClientOptions{})
+	defer client.Close()
+
+	c := &Codex{client: client, transcript: broadcaster}
+	ch := c.TranscriptChannel()
+
+	base.push(`{"id":1,"result":{}}`)
+	if err := client.Call("thread/start", map[string]any{}, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	var sawSent, sawReceived bool
+	for i := 0; i < 2; i++ {
+		entry := <-ch
+		switch entry.Direction {
+		case rpc.Sent:
+			sawSent = true
+		case rpc.Received:
+			sawReceived = true
+		}
+	}
+	if !sawSent || !sawReceived {
+		t.Fatalf("sawSent=%v sawReceived=%v, want both", sawSent, sawReceived)
+	}
+}
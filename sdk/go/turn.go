@@ -0,0 +1,1234 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/openai/codex/sdk/go/protocol"
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// TurnOptions configures a single turn.
+type TurnOptions struct {
+	// OutputSchema, when set, asks the agent to produce JSON conforming to
+	// this JSON schema as its final message.
+	OutputSchema any
+	// MaxRetainedItems, when greater than zero, caps TurnResult.Items to the
+	// last MaxRetainedItems items seen, dropping older ones as new ones
+	// arrive. Notifications still stream through Next/OnHeartbeat as
+	// usual; this only bounds what's accumulated in the result, so very
+	// long turns don't balloon memory. TurnResult.ItemsOverflowed reports
+	// whether anything was dropped.
+	MaxRetainedItems int
+	// OnItem, when set, is called synchronously in the turn loop for every
+	// item/completed notification, in order, before Run/RunWithOptions
+	// returns. Use it for side effects like logging or UI updates when the
+	// ergonomics of a blocking Run are otherwise what you want.
+	OnItem func(item map[string]any)
+	// OnReasoning, when set, is called synchronously in the turn loop with
+	// the text of every completed reasoning item, in order, separately from
+	// OnItem. Use it to drive a "show thinking" toggle without having to
+	// filter reasoning items out of OnItem yourself.
+	OnReasoning func(text string)
+	// Seed, when set, asks the model to sample deterministically for
+	// reproducible evals. It's sent only if the app-server has advertised
+	// the "seed" capability; otherwise it's silently dropped, since older
+	// app-servers neither understand nor need it. Determinism is
+	// best-effort even when honored: it depends on the model provider.
+	Seed *int
+	// TurnInstructions, when set, adds guidance that applies only to this
+	// turn, unlike ThreadStartOptions.Config-level instructions, which
+	// persist across a thread's turns. Use it for one-off steering ("focus
+	// on performance this time") without affecting turns that follow. It's
+	// sent only if the app-server has advertised the "turnInstructions"
+	// capability; otherwise it's silently dropped, since older app-servers
+	// neither understand nor need it.
+	TurnInstructions string
+	// Priority hints how urgently this turn should be serviced relative to
+	// others sharing the same Codex. See Priority and Options.MaxInflight.
+	Priority Priority
+	// RetryPolicy, when its MaxAttempts is greater than one, makes
+	// RunWithOptions automatically restart a turn that fails with a
+	// server-marked-retryable error, up to MaxAttempts total attempts. The
+	// zero value disables this, matching prior behavior: a retryable
+	// failure is returned as-is (StopReason StopFailed, no error) for the
+	// caller to retry itself.
+	RetryPolicy RetryPolicy
+	// AutoCompact, when true, lets the app-server compact the thread's
+	// history automatically mid-turn if it's about to exceed the model's
+	// context window, instead of the turn failing or truncating silently.
+	// The thread id stays the same, so later calls on the Thread continue
+	// seamlessly. It's sent only if the app-server has advertised the
+	// "autoCompact" capability; otherwise it's silently dropped, since
+	// older app-servers neither understand nor need it.
+	AutoCompact bool
+	// Effort sets how much reasoning effort the model should spend on this
+	// turn. It's sent as-is if set.
+	Effort Effort
+	// Summary sets how verbose the model's reasoning summary should be.
+	// It's sent as-is if set.
+	Summary Summary
+	// ApprovalPolicy overrides when the agent pauses to ask for approval
+	// for just this turn, without affecting the thread's own policy. It's
+	// sent as-is if set.
+	ApprovalPolicy ApprovalPolicy
+	// StrictEnums, when true, makes RunStreamed/RunWithOptions reject an
+	// Effort, Summary, or ApprovalPolicy value outside their known
+	// constants with an *EnumValidationError instead of sending it to the
+	// app-server as-is.
+	StrictEnums bool
+	// RequireFinalResponse, when true, makes Run/RunWithOptions return
+	// ErrNoFinalResponse if the turn completes without an assistant message
+	// (TurnResult.FinalResponse left empty), for request/response style
+	// callers that would otherwise have no way to tell a genuinely empty
+	// answer apart from a turn that only ran tool calls.
+	RequireFinalResponse bool
+}
+
+const seedCapability = "seed"
+const turnInstructionsCapability = "turnInstructions"
+const autoCompactCapability = "autoCompact"
+
+// RetryPolicy configures TurnOptions.RetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts bounds how many times RunWithOptions will run the turn
+	// in total (the first attempt plus retries). Zero or one disables
+	// retrying.
+	MaxAttempts int
+	// BaseDelay is how long RunWithOptions waits before each retry.
+	BaseDelay time.Duration
+	// Jitter, when greater than zero, adds a random extra delay in
+	// [0, Jitter) on top of BaseDelay before each retry, so many clients
+	// retrying the same failure don't all resend at once.
+	Jitter time.Duration
+}
+
+// TurnResult is the outcome of a completed turn.
+type TurnResult struct {
+	// TurnID is the app-server's identifier for this turn.
+	TurnID string
+	// Notifications holds every notification observed during the turn, in
+	// arrival order.
+	Notifications []rpc.Notification
+	// Items holds the raw decoded payload of the turn's item/completed
+	// notifications, most recent last. If TurnOptions.MaxRetainedItems was
+	// set and exceeded, only the last MaxRetainedItems are kept; see
+	// ItemsOverflowed.
+	Items []any
+	// ItemsOverflowed is true if TurnOptions.MaxRetainedItems was set and
+	// the turn produced more items than that, so Items no longer holds the
+	// complete set.
+	ItemsOverflowed bool
+	// FinalResponse is the text of the last assistant message item
+	// completed during the turn. It is empty if the turn produced no
+	// assistant message (for example, a turn that only ran tool calls).
+	FinalResponse string
+	// Progress is the last completion estimate the agent self-reported
+	// during the turn, if any.
+	Progress TurnProgress
+	// Usage is the token accounting the app-server reported for the turn.
+	Usage Usage
+	// StopReason is why the turn stopped, as reported on turn/completed.
+	// It is empty until the turn completes.
+	StopReason StopReason
+	// RolloutPath is the on-disk rollout file the app-server recorded for
+	// this turn's thread, or "" for an ephemeral thread. It's set from the
+	// thread itself, so callers don't have to go back through Thread to
+	// find the path to pass to ThreadResumeOptions.Path later.
+	RolloutPath string
+	// RetryCount is how many times RunWithOptions restarted this turn
+	// under TurnOptions.RetryPolicy before producing this result. Zero if
+	// no retry happened, including when RetryPolicy was never set.
+	RetryCount int
+	// Reasoning holds the text of every completed reasoning item observed
+	// during the turn, in arrival order. It is empty for models or turns
+	// that don't emit reasoning items.
+	Reasoning []string
+	// RateLimits is the last account/rateLimits/updated snapshot observed
+	// during the turn, or nil if none arrived. See RateLimitInfo.
+	RateLimits *RateLimitInfo
+
+	// itemTimelines holds every item/started, item/updated, and
+	// item/completed snapshot seen during the turn, keyed by item id, in
+	// arrival order. Read it through ItemTimeline.
+	itemTimelines map[string][]ItemSnapshot
+
+	// inProgressItems holds the most recent payload of every item that has
+	// been reported started but not yet completed, keyed by item id. Read
+	// it through InProgressItems.
+	inProgressItems map[string]map[string]any
+	// inProgressOrder preserves the order items started in, since
+	// inProgressItems is keyed by id and Go map iteration isn't ordered.
+	inProgressOrder []string
+
+	// outputSchema is the TurnOptions.OutputSchema this turn was run with,
+	// if any. DecodeOutput validates against it before unmarshaling.
+	outputSchema any
+}
+
+// ItemSnapshot is a single point in an item's lifecycle, as reported by one
+// item/started, item/updated, or item/completed notification.
+type ItemSnapshot struct {
+	// Status is "started", "updated", or "completed", matching which
+	// notification produced this snapshot.
+	Status string
+	// Item is the item's decoded payload as of this snapshot.
+	Item map[string]any
+	// At is when the app-server reported this snapshot, if it included a
+	// timestamp; otherwise the zero time.
+	At time.Time
+}
+
+// HasFinalResponse reports whether the turn produced an assistant message,
+// so callers can tell that apart from a turn that only ran tool calls
+// without relying on FinalResponse's zero value, which is ambiguous with a
+// model that genuinely replied with an empty string.
+func (r *TurnResult) HasFinalResponse() bool {
+	return r.FinalResponse != ""
+}
+
+// ItemTimeline returns every item/started, item/updated, and item/completed
+// snapshot seen for itemID during the turn, in the order the app-server
+// reported them. This lets a caller show an item's evolution (a command
+// starting, its output growing, a patch being revised) instead of only its
+// final state in Items. It returns nil if itemID never appeared, or if the
+// item payloads in this turn carried no "id" field at all.
+//
+// The app-server reports an item's lifecycle in a fixed order: exactly one
+// item/started, then zero or more item/updated, then exactly one
+// item/completed, all sharing the same item id. TurnResult relies on this
+// ordering both here and in InProgressItems; a turn that's interrupted
+// mid-item may never reach item/completed, in which case the item stays
+// present in InProgressItems and its timeline simply ends at its last
+// item/updated (or item/started, if no update arrived).
+func (r *TurnResult) ItemTimeline(itemID string) []ItemSnapshot {
+	return r.itemTimelines[itemID]
+}
+
+// InProgressItems returns the current payload of every item that has
+// started but not yet completed, in the order each one started. Use it
+// alongside OnItem or a live TurnStream to show a "what's running now" view
+// (a command still executing, output still streaming in) rather than only
+// learning about an item once it's done. An item disappears from this list
+// the moment its item/completed notification arrives.
+func (r *TurnResult) InProgressItems() []map[string]any {
+	items := make([]map[string]any, 0, len(r.inProgressOrder))
+	for _, id := range r.inProgressOrder {
+		if item, ok := r.inProgressItems[id]; ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// StopReason describes why a turn stopped.
+type StopReason string
+
+const (
+	// StopCompleted means the turn finished normally.
+	StopCompleted StopReason = "completed"
+	// StopFailed means the turn failed with an error the app-server marked
+	// retryable (turn/completed's error.willRetry). Any other failure is
+	// returned as a *TurnError instead of a TurnResult.
+	StopFailed StopReason = "failed"
+	// StopMaxTokens means the turn stopped because it hit its output
+	// token limit. Thread.ContinueWithLimit can resume it with a higher
+	// limit without losing the existing context.
+	StopMaxTokens StopReason = "max_tokens"
+)
+
+// Usage holds token accounting for a turn, as reported by the app-server.
+type Usage struct {
+	// InputTokens is the total number of input tokens billed for the turn.
+	InputTokens int
+	// CachedInputTokens is the subset of InputTokens served from prompt
+	// cache rather than freshly processed.
+	CachedInputTokens int
+	// OutputTokens is the number of tokens the model generated.
+	OutputTokens int
+	// ReasoningTokens is the subset of OutputTokens spent on internal
+	// reasoning rather than the visible response, or 0 if the app-server
+	// didn't report it separately.
+	ReasoningTokens int
+	// TotalTokens is the app-server's own reported sum across input and
+	// output, if it sent one; otherwise InputTokens+OutputTokens.
+	TotalTokens int
+}
+
+// Message is the decoded content of an assistant message item, with any
+// citations or file references split out from the raw text rather than
+// flattened into it.
+type Message struct {
+	// Text is the assistant message's text, as returned by extractText.
+	Text string
+	// Annotations are the citations and file references attached to Text,
+	// in the order the app-server reported them.
+	Annotations []Annotation
+}
+
+// Annotation is a single citation or file reference attached to a Message.
+type Annotation struct {
+	// Type is the annotation kind the app-server reported (for example,
+	// "url_citation" or "file_citation").
+	Type string
+	// URL is set for link-style annotations.
+	URL string
+	// Title is a human-readable label for the annotation, if the
+	// app-server provided one.
+	Title string
+	// FileID is set for annotations referencing an uploaded file.
+	FileID string
+	// StartIndex and EndIndex are the UTF-16 code unit offsets into Text
+	// that the annotation covers, if the app-server provided them.
+	StartIndex int
+	EndIndex   int
+}
+
+// FinalMessage decodes the turn's last assistant message item into a
+// Message, or returns nil if the turn produced no assistant message.
+// Unlike FinalResponse, it preserves the message's Annotations instead of
+// reducing it to plain text.
+func (r *TurnResult) FinalMessage() *Message {
+	for i := len(r.Items) - 1; i >= 0; i-- {
+		item, ok := r.Items[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		if itemType, _ := item["type"].(string); itemType != "agent_message" {
+			continue
+		}
+		return decodeMessage(item)
+	}
+	return nil
+}
+
+// decodeMessage builds a Message out of an agent_message item payload.
+func decodeMessage(item map[string]any) *Message {
+	msg := &Message{Text: extractText(item)}
+	raw, ok := item["annotations"].([]any)
+	if !ok {
+		return msg
+	}
+	for _, a := range raw {
+		fields, ok := a.(map[string]any)
+		if !ok {
+			continue
+		}
+		var ann Annotation
+		ann.Type, _ = fields["type"].(string)
+		ann.URL, _ = fields["url"].(string)
+		ann.Title, _ = fields["title"].(string)
+		ann.FileID, _ = fields["fileId"].(string)
+		if v, ok := fields["startIndex"].(float64); ok {
+			ann.StartIndex = int(v)
+		}
+		if v, ok := fields["endIndex"].(float64); ok {
+			ann.EndIndex = int(v)
+		}
+		msg.Annotations = append(msg.Annotations, ann)
+	}
+	return msg
+}
+
+// MessageRole identifies who authored a ChatMessage.
+type MessageRole string
+
+const (
+	MessageRoleUser      MessageRole = "user"
+	MessageRoleAssistant MessageRole = "assistant"
+)
+
+// ChatMessage is one role-tagged entry in a conversation, the shape
+// TurnResult.Messages returns for apps that maintain their own chat history
+// instead of working with raw item payloads. Round-trip it back into a
+// later thread via ChatMessagesToHistory and ThreadResumeOptions.History.
+type ChatMessage struct {
+	Role    MessageRole
+	Content string
+}
+
+// Messages converts the turn's completed items into role-tagged
+// ChatMessage entries, in the order they completed, skipping item types
+// that aren't a user or assistant message. Tool calls, reasoning, and
+// similar items aren't part of the chat transcript an app typically
+// persists, so they're left out rather than forcing a Role for them.
+func (r *TurnResult) Messages() []ChatMessage {
+	var messages []ChatMessage
+	for _, raw := range r.Items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch itemType, _ := item["type"].(string); itemType {
+		case "user_message":
+			messages = append(messages, ChatMessage{Role: MessageRoleUser, Content: extractText(item)})
+		case "agent_message":
+			messages = append(messages, ChatMessage{Role: MessageRoleAssistant, Content: extractText(item)})
+		}
+	}
+	return messages
+}
+
+// ChatMessagesToHistory converts messages into the raw item shape
+// ThreadResumeOptions.History expects, so a caller that persisted
+// TurnResult.Messages rather than raw item payloads can still resume a
+// thread from them.
+func ChatMessagesToHistory(messages []ChatMessage) []any {
+	history := make([]any, len(messages))
+	for i, m := range messages {
+		itemType := "agent_message"
+		if m.Role == MessageRoleUser {
+			itemType = "user_message"
+		}
+		history[i] = map[string]any{"type": itemType, "text": m.Content}
+	}
+	return history
+}
+
+// TranscriptSegment is one piece of a turn's annotated transcript: a
+// single item tagged with the stream it belongs to, so a UI can render
+// reasoning, the final answer, tool activity, and diffs interleaved in
+// the order they actually happened instead of as separate streams.
+type TranscriptSegment struct {
+	// Kind is "reasoning", "answer", "tool", or "diff".
+	Kind string
+	// Text is the segment's text, as returned by extractText.
+	Text string
+	// At is when the app-server reported this item, if it included a
+	// timestamp; otherwise the zero time.
+	At time.Time
+}
+
+// AnnotatedTranscript reconstructs a chronological, tagged transcript from
+// the turn's items. Items the SDK doesn't recognize as reasoning, answer,
+// tool, or diff activity are omitted rather than guessed at.
+func (r *TurnResult) AnnotatedTranscript() []TranscriptSegment {
+	var segments []TranscriptSegment
+	for _, raw := range r.Items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		kind, ok := transcriptKind(item)
+		if !ok {
+			continue
+		}
+		segments = append(segments, TranscriptSegment{
+			Kind: kind,
+			Text: extractText(item),
+			At:   itemTimestamp(item),
+		})
+	}
+	return segments
+}
+
+// transcriptKind maps an item's type to the transcript stream it belongs
+// to, returning false for item types AnnotatedTranscript doesn't surface.
+func transcriptKind(item map[string]any) (string, bool) {
+	switch itemType, _ := item["type"].(string); itemType {
+	case "reasoning":
+		return "reasoning", true
+	case "agent_message":
+		return "answer", true
+	case "command_execution", "web_search", "mcp_tool_call":
+		return "tool", true
+	case "file_change":
+		return "diff", true
+	default:
+		return "", false
+	}
+}
+
+// itemTimestamp decodes an item's RFC 3339 "timestamp" field, returning
+// the zero time if it's absent or malformed.
+func itemTimestamp(item map[string]any) time.Time {
+	raw, _ := item["timestamp"].(string)
+	if raw == "" {
+		return time.Time{}
+	}
+	at, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return at
+}
+
+// CacheHitRatio returns the fraction of input tokens served from prompt
+// cache, in [0, 1]. It returns 0 if Usage.InputTokens is zero, including
+// when no usage data has been reported yet.
+func (r *TurnResult) CacheHitRatio() float64 {
+	if r.Usage.InputTokens == 0 {
+		return 0
+	}
+	return float64(r.Usage.CachedInputTokens) / float64(r.Usage.InputTokens)
+}
+
+// TurnProgress is the agent's self-reported completion estimate for an
+// in-flight turn.
+type TurnProgress struct {
+	// Percent is the agent's self-reported completion estimate in the
+	// range [0, 100], or nil if the turn hasn't reported one yet. A turn
+	// that reports, say, 30% and then immediately completes is worth
+	// flagging as a possible premature stop.
+	Percent *float64
+}
+
+// TurnStream streams notifications for an in-flight turn.
+type TurnStream struct {
+	client           *rpc.Client
+	iterator         *rpc.NotificationIterator
+	threadID         string
+	result           TurnResult
+	maxRetainedItems int
+
+	// OnHeartbeat, when set, is called every time the server emits a
+	// turn/heartbeat notification, indicating the model is still actively
+	// working. Callers driving an idle timeout should reset it here rather
+	// than on every notification, since heartbeats are the only signal
+	// that distinguishes "thinking" from "hung".
+	OnHeartbeat func()
+
+	lastHeartbeat time.Time
+	onClose       func()
+	progress      TurnProgress
+	onItem        func(map[string]any)
+	onReasoning   func(string)
+	span          rpc.Span
+}
+
+// LastHeartbeat returns when the most recent turn/heartbeat notification
+// was observed, or the zero time if none has arrived yet.
+func (s *TurnStream) LastHeartbeat() time.Time {
+	return s.lastHeartbeat
+}
+
+// Progress returns the agent's most recent self-reported completion
+// estimate, or a zero-value TurnProgress if none has arrived yet.
+func (s *TurnStream) Progress() TurnProgress {
+	return s.progress
+}
+
+// ValidateTurn checks that inputs and opts would produce a well-formed
+// turn/start request without contacting the app-server: it applies the same
+// enum and input-path validation RunStreamed does, except the enum check
+// always runs here regardless of TurnOptions.StrictEnums, since an explicit
+// validation call should catch an unrecognized value either way. It
+// supports linting a saved turn configuration in CI before it's ever run.
+func (t *Thread) ValidateTurn(inputs []Input, opts TurnOptions) error {
+	if err := checkEnums(opts); err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		if err := input.validatePath(t.codex.allowedInputRoots); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunStreamed sends inputs to the agent and returns a TurnStream that
+// yields notifications as the turn progresses.
+func (t *Thread) RunStreamed(ctx context.Context, inputs []Input, opts TurnOptions) (*TurnStream, error) {
+	if err := validateEnums(opts); err != nil {
+		return nil, err
+	}
+	for _, input := range inputs {
+		if err := input.validatePath(t.codex.allowedInputRoots); err != nil {
+			return nil, err
+		}
+	}
+	return t.startTurn(ctx, "turn/start", buildTurnParams(t.id, inputs, opts), opts)
+}
+
+// ContinueWithLimit resumes a turn that stopped with StopMaxTokens, raising
+// its output token budget by extraTokens, and blocks until the
+// continuation completes.
+func (t *Thread) ContinueWithLimit(ctx context.Context, extraTokens int, opts TurnOptions) (*TurnResult, error) {
+	stream, err := t.ContinueWithLimitStreamed(ctx, extraTokens, opts)
+	if err != nil {
+		return nil, err
+	}
+	return stream.Wait(ctx)
+}
+
+// ContinueWithLimitStreamed is ContinueWithLimit, returning a TurnStream
+// instead of blocking for the result.
+func (t *Thread) ContinueWithLimitStreamed(ctx context.Context, extraTokens int, opts TurnOptions) (*TurnStream, error) {
+	if err := validateEnums(opts); err != nil {
+		return nil, err
+	}
+	params := map[string]any{
+		"threadId":          t.id,
+		"extraOutputTokens": extraTokens,
+	}
+	if opts.OutputSchema != nil {
+		params["outputSchema"] = opts.OutputSchema
+	}
+	if opts.Effort != "" {
+		params["effort"] = string(opts.Effort)
+	}
+	if opts.Summary != "" {
+		params["summary"] = string(opts.Summary)
+	}
+	if opts.ApprovalPolicy != "" {
+		params["approvalPolicy"] = string(opts.ApprovalPolicy)
+	}
+	return t.startTurn(ctx, "turn/continue", params, opts)
+}
+
+// startTurn sends a turn/start or turn/continue request and wraps the
+// resulting notification stream in a TurnStream, tracking it against
+// Codex.turns so BeginDrain/AwaitDrained see it.
+func (t *Thread) startTurn(ctx context.Context, method string, params map[string]any, opts TurnOptions) (*TurnStream, error) {
+	if err := t.checkNotDeleted(); err != nil {
+		return nil, err
+	}
+	if t.codex.isDraining() {
+		return nil, ErrDraining
+	}
+	t.codex.turns.Add(1)
+
+	if opts.Seed != nil && t.codex.supportsCapability(seedCapability) {
+		params["seed"] = *opts.Seed
+	}
+	if opts.TurnInstructions != "" && t.codex.supportsCapability(turnInstructionsCapability) {
+		params["turnInstructions"] = opts.TurnInstructions
+	}
+	if opts.Priority != PriorityUnspecified && t.codex.supportsCapability(priorityCapability) {
+		params["priority"] = string(opts.Priority)
+	}
+	if opts.AutoCompact && t.codex.supportsCapability(autoCompactCapability) {
+		params["autoCompact"] = true
+	}
+
+	if t.codex.turnQueue != nil {
+		t.codex.turnQueue.acquire(opts.Priority)
+		defer t.codex.turnQueue.release()
+	}
+
+	client := t.codex.currentClient()
+	iterator := client.SubscribeNotificationsFunc(64, func(note rpc.Notification) bool {
+		return matchesThreadID(note, t.id)
+	})
+
+	tracer := t.codex.tracer
+	if tracer == nil {
+		tracer = rpc.NoopTracer()
+	}
+	_, span := tracer.Start(ctx, "turn")
+	span.SetAttribute("codex.threadId", t.id)
+
+	var resp map[string]any
+	if err := client.Call(method, params, &resp); err != nil {
+		span.End()
+		iterator.Close()
+		t.codex.turns.Done()
+		return nil, fmt.Errorf("codex: %s: %w", method, err)
+	}
+
+	stream := &TurnStream{
+		client:           client,
+		iterator:         iterator,
+		threadID:         t.id,
+		onClose:          t.codex.turns.Done,
+		maxRetainedItems: opts.MaxRetainedItems,
+		onItem:           opts.OnItem,
+		onReasoning:      opts.OnReasoning,
+		span:             span,
+	}
+	stream.result.RolloutPath = t.rolloutPath
+	stream.result.outputSchema = opts.OutputSchema
+	return stream, nil
+}
+
+// Next blocks for the next notification belonging to this turn's thread, or
+// returns false once the turn has completed. It returns ctx.Err() if ctx is
+// done first; a notification already queued at that point is left buffered
+// rather than dropped, so a caller with a per-call timeout that loops on
+// Next with a fresh context each time never misses one (see
+// rpc.NotificationIterator.NextCtx).
+func (s *TurnStream) Next(ctx context.Context) (rpc.Notification, bool, error) {
+	for {
+		note, ok, err := s.iterator.NextCtx(ctx)
+		if err != nil {
+			return rpc.Notification{}, false, err
+		}
+		if !ok {
+			return rpc.Notification{}, false, nil
+		}
+		if !matchesThreadID(note, s.threadID) {
+			continue
+		}
+		if s.span != nil {
+			s.span.AddEvent(note.Method, nil)
+		}
+		if note.Method == "turn/heartbeat" {
+			s.lastHeartbeat = time.Now()
+			if s.OnHeartbeat != nil {
+				s.OnHeartbeat()
+			}
+			continue
+		}
+		if note.Method == "turn/progress" {
+			if percent := extractProgress(note); percent != nil {
+				s.progress.Percent = percent
+				s.result.Progress.Percent = percent
+			}
+			continue
+		}
+		updateTurnResult(&s.result, note, s.maxRetainedItems)
+		if note.Method == "item/completed" {
+			if item, ok := itemFromNotification(note); ok {
+				if s.onItem != nil {
+					s.onItem(item)
+				}
+				if s.onReasoning != nil {
+					if itemType, _ := item["type"].(string); itemType == "reasoning" {
+						s.onReasoning(extractText(item))
+					}
+				}
+			}
+		}
+		if err := notificationError(note); err != nil {
+			if turnErr, ok := err.(*TurnError); ok {
+				turnErr.RateLimits = s.result.RateLimits
+			}
+			return note, false, err
+		}
+		if note.Method == "turn/completed" {
+			return note, false, nil
+		}
+		return note, true, nil
+	}
+}
+
+// All returns a range-over-func iterator yielding each notification Next
+// returns for this turn, stopping cleanly once turn/completed arrives (that
+// final notification is not yielded) or Next returns an error, which is
+// yielded as the second value. If the range body breaks early, Close is called so the
+// underlying subscription doesn't leak. Next remains available for callers
+// who aren't on Go 1.23 range-over-func yet.
+func (s *TurnStream) All(ctx context.Context) iter.Seq2[rpc.Notification, error] {
+	return func(yield func(rpc.Notification, error) bool) {
+		for {
+			note, more, err := s.Next(ctx)
+			if err != nil {
+				yield(note, err)
+				s.Close()
+				return
+			}
+			if !more {
+				s.Close()
+				return
+			}
+			if !yield(note, nil) {
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// NextTyped is Next, additionally decoding the notification into the
+// concrete protocol.Event matching its method, for callers who'd rather
+// work with typed fields than map[string]any assertions. It returns a nil
+// event once the turn has completed (more is false) or on error, same as
+// Next's zero-value rpc.Notification.
+func (s *TurnStream) NextTyped(ctx context.Context) (protocol.Event, bool, error) {
+	note, more, err := s.Next(ctx)
+	if err != nil || note.Method == "" {
+		return nil, more, err
+	}
+	event, err := protocol.DecodeNotification(note)
+	if err != nil {
+		return nil, more, err
+	}
+	return event, more, nil
+}
+
+// AwaitItem blocks until the turn produces an item/completed notification
+// whose item type equals kind, returning its decoded payload. It returns
+// an error if the turn completes or fails before such an item appears.
+func (s *TurnStream) AwaitItem(ctx context.Context, kind string) (map[string]any, error) {
+	for {
+		note, more, err := s.Next(ctx)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if note.Method == "item/completed" {
+			if item, ok := itemFromNotification(note); ok {
+				if itemType, _ := item["type"].(string); itemType == kind {
+					return item, nil
+				}
+			}
+		}
+		if !more {
+			s.Close()
+			return nil, fmt.Errorf("codex: turn completed without producing an item of type %q", kind)
+		}
+	}
+}
+
+// turnLogRecord is one line of a RunLogged event log: a notification plus
+// enough identifying context to replay or audit the turn on its own.
+type turnLogRecord struct {
+	ThreadID string `json:"threadId"`
+	TurnID   string `json:"turnId,omitempty"`
+	Method   string `json:"method"`
+	Params   any    `json:"params,omitempty"`
+}
+
+// RunLogged is Run, additionally writing every notification observed
+// during the turn to w as a JSON line, as it arrives. Unlike dumping
+// TurnResult.Notifications once the turn finishes, this writes
+// incrementally, so a crash mid-turn still leaves a partial, valid log
+// instead of nothing at all.
+func (t *Thread) RunLogged(ctx context.Context, inputs []Input, opts TurnOptions, w io.Writer) (*TurnResult, error) {
+	stream, err := t.RunStreamed(ctx, inputs, opts)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(w)
+	for {
+		note, more, runErr := stream.Next(ctx)
+		if note.Method != "" {
+			record := turnLogRecord{
+				ThreadID: stream.threadID,
+				TurnID:   stream.result.TurnID,
+				Method:   note.Method,
+				Params:   note.Params,
+			}
+			if encErr := enc.Encode(record); encErr != nil {
+				stream.Close()
+				return nil, fmt.Errorf("codex: write turn log record: %w", encErr)
+			}
+		}
+		if runErr != nil {
+			stream.Close()
+			return nil, runErr
+		}
+		if !more {
+			stream.Close()
+			return &stream.result, nil
+		}
+	}
+}
+
+// TextDeltas drains the stream, emitting each item/delta notification's
+// text fragment as it arrives, and closing the returned channel once the
+// turn completes or fails. Like Wait and AwaitItem, it fully consumes the
+// stream to do this, so a given TurnStream should be driven by only one of
+// Next/Wait/AwaitItem/TextDeltas. TurnResult.FinalResponse still reflects
+// only the last item/completed payload, not a concatenation of deltas, so
+// there's no duplication between live text and the final result; Wait can
+// be called afterward to retrieve that result; it returns immediately
+// since the stream is already drained by then.
+func (s *TurnStream) TextDeltas(ctx context.Context) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			note, more, err := s.Next(ctx)
+			if err == nil && note.Method == "item/delta" {
+				if delta, ok := textDeltaFromNotification(note); ok {
+					select {
+					case out <- delta:
+					case <-ctx.Done():
+						s.Close()
+						return
+					}
+				}
+			}
+			if err != nil || !more {
+				s.Close()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// textDeltaFromNotification returns the "delta" field of an item/delta
+// notification's params, if present.
+func textDeltaFromNotification(note rpc.Notification) (string, bool) {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	delta, ok := params["delta"].(string)
+	return delta, ok
+}
+
+// Wait drains the stream until the turn completes and returns the
+// accumulated result.
+func (s *TurnStream) Wait(ctx context.Context) (*TurnResult, error) {
+	for {
+		_, more, err := s.Next(ctx)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if !more {
+			s.Close()
+			return &s.result, nil
+		}
+	}
+}
+
+// RunWithCallback sends inputs to the agent and invokes callback for every
+// notification the turn emits, the same ones RunStreamed's Next would
+// yield, stopping early and returning callback's error if it returns one.
+// It's a thin wrapper over RunStreamed for callers who prefer registering
+// a handler over driving an iterator themselves, useful for wiring
+// straight into a UI's event-handling style.
+func (t *Thread) RunWithCallback(ctx context.Context, inputs []Input, opts TurnOptions, callback func(rpc.Notification) error) (*TurnResult, error) {
+	stream, err := t.RunStreamed(ctx, inputs, opts)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		note, more, err := stream.Next(ctx)
+		if err != nil {
+			stream.Close()
+			return nil, err
+		}
+		if !more {
+			stream.Close()
+			if note.Method != "" {
+				if err := callback(note); err != nil {
+					return &stream.result, err
+				}
+			}
+			return &stream.result, nil
+		}
+		if err := callback(note); err != nil {
+			stream.Close()
+			return &stream.result, err
+		}
+	}
+}
+
+// Close stops receiving notifications for this turn. It does not stop the
+// turn server-side; the agent keeps running (and burning tokens) unless
+// the caller also calls CloseAndInterrupt.
+func (s *TurnStream) Close() {
+	if s.span != nil {
+		s.span.SetAttribute("codex.usage.inputTokens", s.result.Usage.InputTokens)
+		s.span.SetAttribute("codex.usage.outputTokens", s.result.Usage.OutputTokens)
+		s.span.SetAttribute("codex.usage.totalTokens", s.result.Usage.TotalTokens)
+		s.span.SetAttribute("codex.stopReason", string(s.result.StopReason))
+		s.span.End()
+		s.span = nil
+	}
+	s.iterator.Close()
+	if s.onClose != nil {
+		s.onClose()
+		s.onClose = nil
+	}
+}
+
+// CloseAndInterrupt is Close, but first asks the app-server to interrupt
+// the turn via turn/interrupt, so abandoning a stream actually stops the
+// agent rather than letting it continue invisibly in the background. It
+// is a no-op interrupt (but still closes) if no turn id has been observed
+// yet.
+func (s *TurnStream) CloseAndInterrupt(ctx context.Context) error {
+	turnID := s.result.TurnID
+	defer s.Close()
+	if turnID == "" {
+		return nil
+	}
+	if err := s.client.Call("turn/interrupt", map[string]any{
+		"threadId": s.threadID,
+		"turnId":   turnID,
+	}, nil); err != nil {
+		return fmt.Errorf("codex: turn/interrupt: %w", err)
+	}
+	return nil
+}
+
+func buildTurnParams(threadID string, inputs []Input, opts TurnOptions) map[string]any {
+	items := make([]map[string]any, len(inputs))
+	for i, input := range inputs {
+		items[i] = input.toParam()
+	}
+	params := map[string]any{
+		"threadId": threadID,
+		"input":    items,
+	}
+	if opts.OutputSchema != nil {
+		params["outputSchema"] = opts.OutputSchema
+	}
+	if opts.Effort != "" {
+		params["effort"] = string(opts.Effort)
+	}
+	if opts.Summary != "" {
+		params["summary"] = string(opts.Summary)
+	}
+	if opts.ApprovalPolicy != "" {
+		params["approvalPolicy"] = string(opts.ApprovalPolicy)
+	}
+	return params
+}
+
+// extractThreadID returns the thread id carried by a notification, or "" if
+// the notification doesn't reference one.
+func extractThreadID(note rpc.Notification) string {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return ""
+	}
+	id, _ := params["threadId"].(string)
+	return id
+}
+
+// matchesThreadID reports whether note belongs to threadID.
+func matchesThreadID(note rpc.Notification, threadID string) bool {
+	id := extractThreadID(note)
+	return id == "" || id == threadID
+}
+
+// extractTurnID returns the turn id carried by a notification, or "" if
+// absent.
+func extractTurnID(note rpc.Notification) string {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if id, ok := params["turnId"].(string); ok {
+		return id
+	}
+	if turn, ok := params["turn"].(map[string]any); ok {
+		id, _ := turn["id"].(string)
+		return id
+	}
+	return ""
+}
+
+// itemFromNotification returns the decoded "item" payload of an
+// item/completed notification, if present.
+func itemFromNotification(note rpc.Notification) (map[string]any, bool) {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	item, ok := params["item"].(map[string]any)
+	return item, ok
+}
+
+// extractText returns the "text" field of an item payload, if present.
+func extractText(item map[string]any) string {
+	text, _ := item["text"].(string)
+	return text
+}
+
+// extractUsage decodes the usage object nested in a turn/completed
+// notification's turn field, returning false if none is present.
+func extractUsage(note rpc.Notification) (Usage, bool) {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return Usage{}, false
+	}
+	turn, ok := params["turn"].(map[string]any)
+	if !ok {
+		return Usage{}, false
+	}
+	usage, ok := turn["usage"].(map[string]any)
+	if !ok {
+		return Usage{}, false
+	}
+	var u Usage
+	if v, ok := usage["inputTokens"].(float64); ok {
+		u.InputTokens = int(v)
+	}
+	if v, ok := usage["cachedInputTokens"].(float64); ok {
+		u.CachedInputTokens = int(v)
+	}
+	if v, ok := usage["outputTokens"].(float64); ok {
+		u.OutputTokens = int(v)
+	}
+	if v, ok := usage["reasoningTokens"].(float64); ok {
+		u.ReasoningTokens = int(v)
+	}
+	if v, ok := usage["totalTokens"].(float64); ok {
+		u.TotalTokens = int(v)
+	} else {
+		u.TotalTokens = u.InputTokens + u.OutputTokens
+	}
+	return u, true
+}
+
+// extractStopReason decodes the status field nested in a turn/completed
+// notification's turn object into a StopReason, or "" if absent.
+func extractStopReason(note rpc.Notification) StopReason {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return ""
+	}
+	turn, ok := params["turn"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	status, _ := turn["status"].(string)
+	return StopReason(status)
+}
+
+// extractProgress decodes a turn/progress notification's percentage field,
+// returning nil if the notification doesn't carry one.
+func extractProgress(note rpc.Notification) *float64 {
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return nil
+	}
+	percent, ok := params["percentage"].(float64)
+	if !ok {
+		return nil
+	}
+	return &percent
+}
+
+// notificationError returns a non-nil error if note is a turn/completed
+// notification reporting a non-retryable failure.
+func notificationError(note rpc.Notification) error {
+	if note.Method != "turn/completed" {
+		return nil
+	}
+	params, ok := note.Params.(map[string]any)
+	if !ok {
+		return nil
+	}
+	turn, ok := params["turn"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	if status, _ := turn["status"].(string); status != "failed" {
+		return nil
+	}
+	errPayload, _ := turn["error"].(map[string]any)
+	if willRetry, _ := errPayload["willRetry"].(bool); willRetry {
+		return nil
+	}
+	message, _ := errPayload["message"].(string)
+	code, _ := errPayload["code"].(float64)
+	return &TurnError{Code: int(code), Message: message}
+}
+
+// updateTurnResult folds a single notification into result, populating
+// TurnID, Notifications, Items, and FinalResponse as applicable. If
+// maxRetainedItems is greater than zero, Items is capped to its last
+// maxRetainedItems entries and ItemsOverflowed is set once anything is
+// dropped.
+//
+// FinalResponse only ever reflects the last item/completed notification
+// whose item is the assistant's own message (type "agent_message"), not
+// just the last text seen. A tool's completed item can carry a "text"
+// field too (for example a web search summary), and naively taking the
+// last text would let that overwrite the real answer.
+func updateTurnResult(result *TurnResult, note rpc.Notification, maxRetainedItems int) {
+	if turnID := extractTurnID(note); turnID != "" {
+		result.TurnID = turnID
+	}
+	result.Notifications = append(result.Notifications, note)
+
+	if note.Method == "turn/completed" {
+		if usage, ok := extractUsage(note); ok {
+			result.Usage = usage
+		}
+		result.StopReason = extractStopReason(note)
+	}
+
+	if rateLimits, ok := decodeRateLimitInfo(note); ok {
+		result.RateLimits = rateLimits
+	}
+
+	switch note.Method {
+	case "item/started", "item/updated", "item/completed":
+	default:
+		return
+	}
+	item, ok := itemFromNotification(note)
+	if !ok {
+		return
+	}
+	recordItemSnapshot(result, note.Method, item)
+
+	switch note.Method {
+	case "item/started":
+		trackItemStarted(result, item)
+		return
+	case "item/updated":
+		return
+	}
+
+	trackItemCompleted(result, item)
+	result.Items = append(result.Items, item)
+	if maxRetainedItems > 0 && len(result.Items) > maxRetainedItems {
+		result.Items = result.Items[len(result.Items)-maxRetainedItems:]
+		result.ItemsOverflowed = true
+	}
+
+	switch itemType, _ := item["type"].(string); itemType {
+	case "agent_message":
+		result.FinalResponse = extractText(item)
+	case "reasoning":
+		result.Reasoning = append(result.Reasoning, extractText(item))
+	}
+}
+
+// trackItemStarted records item as in progress in result.InProgressItems,
+// preserving the order items started in.
+func trackItemStarted(result *TurnResult, item map[string]any) {
+	id, _ := item["id"].(string)
+	if id == "" {
+		return
+	}
+	if result.inProgressItems == nil {
+		result.inProgressItems = map[string]map[string]any{}
+	}
+	if _, exists := result.inProgressItems[id]; !exists {
+		result.inProgressOrder = append(result.inProgressOrder, id)
+	}
+	result.inProgressItems[id] = item
+}
+
+// trackItemCompleted removes item from result.InProgressItems, now that its
+// item/completed notification has arrived.
+func trackItemCompleted(result *TurnResult, item map[string]any) {
+	id, _ := item["id"].(string)
+	if id == "" {
+		return
+	}
+	delete(result.inProgressItems, id)
+}
+
+// recordItemSnapshot appends an ItemSnapshot to the item's timeline, keyed
+// by its "id" field. Items without an id can't be correlated across
+// notifications, so they're left out of the timeline entirely.
+func recordItemSnapshot(result *TurnResult, method string, item map[string]any) {
+	id, _ := item["id"].(string)
+	if id == "" {
+		return
+	}
+	var status string
+	switch method {
+	case "item/started":
+		status = "started"
+	case "item/completed":
+		status = "completed"
+	default:
+		status = "updated"
+	}
+	if result.itemTimelines == nil {
+		result.itemTimelines = map[string][]ItemSnapshot{}
+	}
+	result.itemTimelines[id] = append(result.itemTimelines[id], ItemSnapshot{
+		Status: status,
+		Item:   item,
+		At:     itemTimestamp(item),
+	})
+}
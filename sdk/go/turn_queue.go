@@ -0,0 +1,62 @@
+package codex
+
+import "sync"
+
+// turnQueue bounds how many turn/start or turn/continue requests can be
+// in flight (sent, awaiting their ack) at once, and orders waiters by
+// Priority so an interactive turn dispatches ahead of queued background
+// ones sharing the same Codex. A nil *turnQueue disables limiting
+// entirely, which is the default (Options.MaxInflight <= 0).
+type turnQueue struct {
+	max int
+
+	mu          sync.Mutex
+	inFlight    int
+	interactive []chan struct{}
+	background  []chan struct{}
+}
+
+func newTurnQueue(max int) *turnQueue {
+	return &turnQueue{max: max}
+}
+
+// acquire blocks until a slot is free, respecting priority order among
+// waiters: every queued interactive waiter is released before any queued
+// background waiter. PriorityUnspecified queues as interactive, so a turn
+// that doesn't set Priority isn't starved behind an explicit background
+// backlog.
+func (q *turnQueue) acquire(priority Priority) {
+	q.mu.Lock()
+	if q.inFlight < q.max {
+		q.inFlight++
+		q.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	if priority == PriorityBackground {
+		q.background = append(q.background, ch)
+	} else {
+		q.interactive = append(q.interactive, ch)
+	}
+	q.mu.Unlock()
+	<-ch
+}
+
+// release frees the caller's slot, handing it directly to the
+// highest-priority waiter if one is queued, or returning it to the pool.
+func (q *turnQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next chan struct{}
+	if len(q.interactive) > 0 {
+		next = q.interactive[0]
+		q.interactive = q.interactive[1:]
+	} else if len(q.background) > 0 {
+		next = q.background[0]
+		q.background = q.background[1:]
+	} else {
+		q.inFlight--
+		return
+	}
+	close(next)
+}
@@ -0,0 +1,63 @@
+package codex
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTurnQueueAllowsUpToMaxWithoutBlocking(t *testing.T) {
+	q := newTurnQueue(2)
+
+	done := make(chan struct{})
+	go func() {
+		q.acquire(PriorityInteractive)
+		q.acquire(PriorityInteractive)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("acquire blocked despite slots being available")
+	}
+}
+
+func TestTurnQueuePrefersInteractiveOverBackground(t *testing.T) {
+	q := newTurnQueue(1)
+	q.acquire(PriorityInteractive) // fills the only slot
+
+	var order []string
+	var mu sync.Mutex
+
+	backgroundQueued := make(chan struct{})
+	go func() {
+		close(backgroundQueued)
+		q.acquire(PriorityBackground)
+		mu.Lock()
+		order = append(order, "background")
+		mu.Unlock()
+	}()
+	<-backgroundQueued
+	time.Sleep(20 * time.Millisecond) // let background enqueue first
+
+	interactiveQueued := make(chan struct{})
+	go func() {
+		close(interactiveQueued)
+		q.acquire(PriorityInteractive)
+		mu.Lock()
+		order = append(order, "interactive")
+		mu.Unlock()
+	}()
+	<-interactiveQueued
+	time.Sleep(20 * time.Millisecond) // let interactive enqueue behind it
+
+	q.release() // frees the original slot to the highest-priority waiter
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) == 0 || order[0] != "interactive" {
+		t.Fatalf("order = %v, want interactive dispatched before background", order)
+	}
+}
@@ -0,0 +1,418 @@
+package codex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openai/codex/sdk/go/protocol"
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+// fakeTransport is an in-memory rpc.Transport driven entirely by the test:
+// WriteLine records what was sent (the test usually doesn't care, but some
+// tests assert on it), and lines queued with push are returned by ReadLine
+// in order. A test with more than one round trip should not push every
+// response up front: the client's read loop drains whatever is already
+// buffered as soon as it's scheduled, so a later response pushed before its
+// request is even sent can be dispatched before anything is listening for
+// it and silently dropped. Setting onWrite lets a test push each round's
+// response only once the corresponding request has actually gone out,
+// after its id is registered to receive the reply.
+type fakeTransport struct {
+	lines   chan string
+	written []string
+	onWrite func(n int, line string)
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{lines: make(chan string, 16)}
+}
+
+func (t *fakeTransport) push(line string) { t.lines <- line }
+
+func (t *fakeTransport) ReadLine() (string, error) {
+	line, ok := <-t.lines
+	if !ok {
+		return "", context.Canceled
+	}
+	return line, nil
+}
+
+func (t *fakeTransport) WriteLine(line string) error {
+	t.written = append(t.written, line)
+	if t.onWrite != nil {
+		t.onWrite(len(t.written), line)
+	}
+	return nil
+}
+
+func (t *fakeTransport) Close() error {
+	close(t.lines)
+	return nil
+}
+
+func notificationLine(t *testing.T, method string, params map[string]any) string {
+	t.Helper()
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("marshal params: %v", err)
+	}
+	line, err := json.Marshal(map[string]any{"method": method, "params": json.RawMessage(paramsJSON)})
+	if err != nil {
+		t.Fatalf("marshal notification: %v", err)
+	}
+	return string(line)
+}
+
+func TestTurnStreamHeartbeatResetsIdleSignalWithoutSurfacingAsItem(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+	defer stream.Close()
+
+	var heartbeats int
+	stream.OnHeartbeat = func() { heartbeats++ }
+
+	transport.push(notificationLine(t, "turn/heartbeat", map[string]any{"threadId": "thread-1"}))
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "hi"},
+	}))
+
+	note, more, err := stream.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if !more {
+		t.Fatalf("expected more notifications after the item")
+	}
+	if note.Method != "item/completed" {
+		t.Fatalf("expected the heartbeat to be consumed internally, got %q", note.Method)
+	}
+	if heartbeats != 1 {
+		t.Fatalf("heartbeats = %d, want 1", heartbeats)
+	}
+	if stream.LastHeartbeat().IsZero() {
+		t.Fatalf("LastHeartbeat() should be set after a heartbeat notification")
+	}
+	if time.Since(stream.LastHeartbeat()) > time.Second {
+		t.Fatalf("LastHeartbeat() should be recent")
+	}
+}
+
+func TestTurnStreamProgressUpdatesFromNotifications(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+	defer stream.Close()
+
+	transport.push(notificationLine(t, "turn/progress", map[string]any{"threadId": "thread-1", "percentage": 30.0}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{"threadId": "thread-1", "turnId": "turn-1"}))
+
+	note, more, err := stream.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if more || note.Method != "turn/completed" {
+		t.Fatalf("expected the progress update to be consumed internally, got method %q more %v", note.Method, more)
+	}
+
+	progress := stream.Progress()
+	if progress.Percent == nil || *progress.Percent != 30.0 {
+		t.Fatalf("Progress().Percent = %v, want 30", progress.Percent)
+	}
+	if result := stream.result.Progress; result.Percent == nil || *result.Percent != 30.0 {
+		t.Fatalf("result.Progress.Percent = %v, want 30", result.Percent)
+	}
+}
+
+func TestCloseAndInterruptSendsTurnInterrupt(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+	stream.result.TurnID = "turn-1"
+
+	transport.push(`{"id":1,"result":{}}`)
+
+	if err := stream.CloseAndInterrupt(context.Background()); err != nil {
+		t.Fatalf("CloseAndInterrupt: %v", err)
+	}
+
+	if len(transport.written) != 1 {
+		t.Fatalf("written = %v, want exactly one request", transport.written)
+	}
+	var req map[string]any
+	if err := json.Unmarshal([]byte(transport.written[0]), &req); err != nil {
+		t.Fatalf("unmarshal request: %v", err)
+	}
+	if req["method"] != "turn/interrupt" {
+		t.Fatalf("method = %v, want turn/interrupt", req["method"])
+	}
+	params, _ := req["params"].(map[string]any)
+	if params["threadId"] != "thread-1" || params["turnId"] != "turn-1" {
+		t.Fatalf("params = %+v, want thread-1/turn-1", params)
+	}
+}
+
+func TestCloseAndInterruptSkipsRequestWithoutTurnID(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	if err := stream.CloseAndInterrupt(context.Background()); err != nil {
+		t.Fatalf("CloseAndInterrupt: %v", err)
+	}
+	if len(transport.written) != 0 {
+		t.Fatalf("written = %v, want no request without an observed turn id", transport.written)
+	}
+}
+
+func TestAwaitItemReturnsFirstMatchingItem(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "command_execution", "text": "ran ls"},
+	}))
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "file_change", "paths": []any{"a.go"}},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	item, err := stream.AwaitItem(context.Background(), "file_change")
+	if err != nil {
+		t.Fatalf("AwaitItem: %v", err)
+	}
+	if itemType, _ := item["type"].(string); itemType != "file_change" {
+		t.Fatalf("item = %+v, want type file_change", item)
+	}
+}
+
+func TestAwaitItemErrorsIfTurnCompletesWithoutIt(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	if _, err := stream.AwaitItem(context.Background(), "file_change"); err == nil {
+		t.Fatal("AwaitItem: want error when the turn never produces the requested item type")
+	}
+}
+
+func TestNextReturnsCtxErrWithoutDroppingAQueuedNotification(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := stream.Next(ctx); err == nil {
+		t.Fatal("Next() = nil error, want ctx.Err() before any notification arrived")
+	}
+
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "hi"},
+	}))
+
+	note, more, err := stream.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next() with a fresh context: %v", err)
+	}
+	if !more || note.Method != "item/completed" {
+		t.Fatalf("note = %+v, more = %v, want the queued item/completed notification", note, more)
+	}
+}
+
+func TestNextAttachesRateLimitsToTurnErrorOnFailure(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	transport.push(notificationLine(t, "account/rateLimits/updated", map[string]any{
+		"rateLimits": map[string]any{
+			"primary": map[string]any{"usedPercent": 95.0},
+		},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn": map[string]any{
+			"status": "failed",
+			"error":  map[string]any{"message": "usage limit reached"},
+		},
+	}))
+
+	for {
+		_, more, err := stream.Next(context.Background())
+		if err != nil {
+			var turnErr *TurnError
+			if !errors.As(err, &turnErr) {
+				t.Fatalf("Next error = %v, want *TurnError", err)
+			}
+			if turnErr.RateLimits == nil || turnErr.RateLimits.Primary == nil || turnErr.RateLimits.Primary.UsedPercent != 95 {
+				t.Fatalf("turnErr.RateLimits = %+v, want Primary.UsedPercent 95", turnErr.RateLimits)
+			}
+			return
+		}
+		if !more {
+			t.Fatal("Next: want an error before the stream ends")
+		}
+	}
+}
+
+func TestNextTypedDecodesItemCompletedEvent(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "done"},
+	}))
+
+	event, more, err := stream.NextTyped(context.Background())
+	if err != nil {
+		t.Fatalf("NextTyped: %v", err)
+	}
+	if !more {
+		t.Fatalf("more = false, want true for an item/completed notification")
+	}
+	item, ok := event.(protocol.ItemCompletedEvent)
+	if !ok {
+		t.Fatalf("event = %T, want protocol.ItemCompletedEvent", event)
+	}
+	if item.ThreadID != "thread-1" || item.TurnID != "turn-1" {
+		t.Fatalf("item = %+v, want threadId/turnId populated", item)
+	}
+}
+
+func TestTextDeltasYieldsFragmentsThenClosesAtTurnCompletion(t *testing.T) {
+	transport := newFakeTransport()
+	client := rpc.NewClient(transport, rpc.ClientOptions{})
+	defer client.Close()
+
+	stream := &TurnStream{
+		client:   client,
+		iterator: client.SubscribeNotifications(8),
+		threadID: "thread-1",
+	}
+
+	transport.push(notificationLine(t, "item/delta", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"itemId":   "item-1",
+		"delta":    "Hel",
+	}))
+	transport.push(notificationLine(t, "item/delta", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"itemId":   "item-1",
+		"delta":    "lo",
+	}))
+	transport.push(notificationLine(t, "item/completed", map[string]any{
+		"threadId": "thread-1",
+		"turnId":   "turn-1",
+		"item":     map[string]any{"type": "agent_message", "text": "Hello"},
+	}))
+	transport.push(notificationLine(t, "turn/completed", map[string]any{
+		"threadId": "thread-1",
+		"turn":     map[string]any{"status": "completed"},
+	}))
+
+	var fragments []string
+	for fragment := range stream.TextDeltas(context.Background()) {
+		fragments = append(fragments, fragment)
+	}
+
+	if len(fragments) != 2 || fragments[0] != "Hel" || fragments[1] != "lo" {
+		t.Fatalf("fragments = %v, want [Hel lo]", fragments)
+	}
+	if stream.result.FinalResponse != "Hello" {
+		t.Fatalf("FinalResponse = %q, want the item/completed text, not a concatenation of deltas", stream.result.FinalResponse)
+	}
+
+	result, err := stream.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait after TextDeltas: %v", err)
+	}
+	if result.FinalResponse != "Hello" {
+		t.Fatalf("Wait().FinalResponse = %q, want Hello", result.FinalResponse)
+	}
+}
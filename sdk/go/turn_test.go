@@ -0,0 +1,467 @@
+package codex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openai/codex/sdk/go/rpc"
+)
+
+func TestValidateTurnRejectsUnknownApprovalPolicyRegardlessOfStrictEnums(t *testing.T) {
+	thread := &Thread{codex: &Codex{}, id: "thread-1"}
+	opts := TurnOptions{ApprovalPolicy: ApprovalPolicy("not-a-real-policy")}
+
+	var enumErr *EnumValidationError
+	if err := thread.ValidateTurn(nil, opts); !errors.As(err, &enumErr) {
+		t.Fatalf("ValidateTurn() = %v, want an *EnumValidationError even with StrictEnums unset", err)
+	}
+}
+
+func TestValidateTurnRejectsDisallowedInputPath(t *testing.T) {
+	c := &Codex{allowedInputRoots: []string{"/workspace"}}
+	thread := &Thread{codex: c, id: "thread-1"}
+
+	input, err := FileInput("/etc/passwd")
+	if err != nil {
+		t.Fatalf("FileInput: %v", err)
+	}
+	if err := thread.ValidateTurn([]Input{input}, TurnOptions{}); !errors.Is(err, ErrPathNotAllowed) {
+		t.Fatalf("ValidateTurn() = %v, want ErrPathNotAllowed", err)
+	}
+}
+
+func TestValidateTurnAcceptsWellFormedTurn(t *testing.T) {
+	thread := &Thread{codex: &Codex{}, id: "thread-1"}
+	opts := TurnOptions{Effort: EffortHigh, Summary: SummaryConcise}
+	if err := thread.ValidateTurn([]Input{TextInput("hello")}, opts); err != nil {
+		t.Fatalf("ValidateTurn() = %v, want nil", err)
+	}
+}
+
+func TestHasFinalResponse(t *testing.T) {
+	var result TurnResult
+	if result.HasFinalResponse() {
+		t.Fatal("HasFinalResponse() = true, want false for a zero-value TurnResult")
+	}
+	result.FinalResponse = "done"
+	if !result.HasFinalResponse() {
+		t.Fatal("HasFinalResponse() = false, want true once FinalResponse is set")
+	}
+}
+
+func TestTurnResultMessagesExtractsUserAndAssistantOnly(t *testing.T) {
+	var result TurnResult
+	for _, item := range []map[string]any{
+		{"type": "user_message", "text": "hello"},
+		{"type": "command_execution", "text": "ls"},
+		{"type": "agent_message", "text": "hi there"},
+	} {
+		updateTurnResult(&result, rpc.Notification{
+			Method: "item/completed",
+			Params: map[string]any{"threadId": "thread-1", "turnId": "turn-1", "item": item},
+		}, 0)
+	}
+
+	messages := result.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("len(messages) = %d, want 2, got %+v", len(messages), messages)
+	}
+	if messages[0].Role != MessageRoleUser || messages[0].Content != "hello" {
+		t.Fatalf("messages[0] = %+v, want user/hello", messages[0])
+	}
+	if messages[1].Role != MessageRoleAssistant || messages[1].Content != "hi there" {
+		t.Fatalf("messages[1] = %+v, want assistant/hi there", messages[1])
+	}
+}
+
+func TestChatMessagesToHistoryRoundTrips(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: MessageRoleUser, Content: "hello"},
+		{Role: MessageRoleAssistant, Content: "hi there"},
+	}
+
+	history := ChatMessagesToHistory(messages)
+	if len(history) != 2 {
+		t.Fatalf("len(history) = %d, want 2", len(history))
+	}
+	entry, ok := history[0].(map[string]any)
+	if !ok || entry["type"] != "user_message" || entry["text"] != "hello" {
+		t.Fatalf("history[0] = %+v, want a user_message entry", history[0])
+	}
+	entry, ok = history[1].(map[string]any)
+	if !ok || entry["type"] != "agent_message" || entry["text"] != "hi there" {
+		t.Fatalf("history[1] = %+v, want an agent_message entry", history[1])
+	}
+}
+
+func TestUpdateTurnResultFinalResponseIgnoresToolText(t *testing.T) {
+	var result TurnResult
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"threadId": "thread-1",
+			"turnId":   "turn-1",
+			"item": map[string]any{
+				"type": "web_search",
+				"text": "a tool's text output",
+			},
+		},
+	}, 0)
+	if result.FinalResponse != "" {
+		t.Fatalf("FinalResponse = %q, want empty after a non-assistant item", result.FinalResponse)
+	}
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"threadId": "thread-1",
+			"turnId":   "turn-1",
+			"item": map[string]any{
+				"type": "agent_message",
+				"text": "the real answer",
+			},
+		},
+	}, 0)
+	if result.FinalResponse != "the real answer" {
+		t.Fatalf("FinalResponse = %q, want %q", result.FinalResponse, "the real answer")
+	}
+
+	// A later tool item must not overwrite the assistant's final message.
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"threadId": "thread-1",
+			"turnId":   "turn-1",
+			"item": map[string]any{
+				"type": "command_execution",
+				"text": "irrelevant",
+			},
+		},
+	}, 0)
+	if result.FinalResponse != "the real answer" {
+		t.Fatalf("FinalResponse = %q, want unchanged %q", result.FinalResponse, "the real answer")
+	}
+	if len(result.Items) != 3 {
+		t.Fatalf("len(Items) = %d, want 3", len(result.Items))
+	}
+}
+
+func TestUpdateTurnResultCapsItemsToMaxRetained(t *testing.T) {
+	var result TurnResult
+
+	for i := 0; i < 5; i++ {
+		updateTurnResult(&result, rpc.Notification{
+			Method: "item/completed",
+			Params: map[string]any{
+				"item": map[string]any{
+					"type": "command_execution",
+					"text": "irrelevant",
+				},
+			},
+		}, 2)
+	}
+
+	if len(result.Items) != 2 {
+		t.Fatalf("len(Items) = %d, want 2", len(result.Items))
+	}
+	if !result.ItemsOverflowed {
+		t.Fatalf("ItemsOverflowed = false, want true")
+	}
+}
+
+func TestUpdateTurnResultExtractsUsage(t *testing.T) {
+	var result TurnResult
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "turn/completed",
+		Params: map[string]any{
+			"turn": map[string]any{
+				"status": "completed",
+				"usage": map[string]any{
+					"inputTokens":       100.0,
+					"cachedInputTokens": 80.0,
+					"outputTokens":      20.0,
+				},
+			},
+		},
+	}, 0)
+
+	if result.Usage.InputTokens != 100 || result.Usage.CachedInputTokens != 80 || result.Usage.OutputTokens != 20 {
+		t.Fatalf("Usage = %+v, want {100 80 20}", result.Usage)
+	}
+	if ratio := result.CacheHitRatio(); ratio != 0.8 {
+		t.Fatalf("CacheHitRatio() = %v, want 0.8", ratio)
+	}
+}
+
+func TestUpdateTurnResultExtractsReasoningAndTotalTokens(t *testing.T) {
+	var result TurnResult
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "turn/completed",
+		Params: map[string]any{
+			"turn": map[string]any{
+				"status": "completed",
+				"usage": map[string]any{
+					"inputTokens":     100.0,
+					"outputTokens":    20.0,
+					"reasoningTokens": 12.0,
+				},
+			},
+		},
+	}, 0)
+
+	if result.Usage.ReasoningTokens != 12 {
+		t.Fatalf("ReasoningTokens = %d, want 12", result.Usage.ReasoningTokens)
+	}
+	if result.Usage.TotalTokens != 120 {
+		t.Fatalf("TotalTokens = %d, want 120 (derived from input+output when unreported)", result.Usage.TotalTokens)
+	}
+}
+
+func TestCacheHitRatioZeroWithNoUsage(t *testing.T) {
+	var result TurnResult
+	if ratio := result.CacheHitRatio(); ratio != 0 {
+		t.Fatalf("CacheHitRatio() = %v, want 0", ratio)
+	}
+}
+
+func TestUpdateTurnResultExtractsStopReason(t *testing.T) {
+	var result TurnResult
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "turn/completed",
+		Params: map[string]any{
+			"turn": map[string]any{"status": "max_tokens"},
+		},
+	}, 0)
+
+	if result.StopReason != StopMaxTokens {
+		t.Fatalf("StopReason = %q, want %q", result.StopReason, StopMaxTokens)
+	}
+}
+
+func TestFinalMessageDecodesAnnotations(t *testing.T) {
+	var result TurnResult
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{
+				"type": "agent_message",
+				"text": "see the docs",
+				"annotations": []any{
+					map[string]any{
+						"type":       "url_citation",
+						"url":        "https://example.com/docs",
+						"title":      "Docs",
+						"startIndex": 4.0,
+						"endIndex":   8.0,
+					},
+				},
+			},
+		},
+	}, 0)
+
+	msg := result.FinalMessage()
+	if msg == nil {
+		t.Fatal("FinalMessage() = nil, want a decoded message")
+	}
+	if msg.Text != "see the docs" {
+		t.Fatalf("Text = %q, want %q", msg.Text, "see the docs")
+	}
+	if len(msg.Annotations) != 1 {
+		t.Fatalf("len(Annotations) = %d, want 1", len(msg.Annotations))
+	}
+	ann := msg.Annotations[0]
+	if ann.Type != "url_citation" || ann.URL != "https://example.com/docs" || ann.Title != "Docs" {
+		t.Fatalf("Annotations[0] = %+v", ann)
+	}
+	if ann.StartIndex != 4 || ann.EndIndex != 8 {
+		t.Fatalf("Annotations[0] offsets = %d,%d, want 4,8", ann.StartIndex, ann.EndIndex)
+	}
+}
+
+func TestFinalMessageNilWithoutAssistantMessage(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"type": "command_execution", "text": "ran ls"},
+		},
+	}, 0)
+
+	if msg := result.FinalMessage(); msg != nil {
+		t.Fatalf("FinalMessage() = %+v, want nil", msg)
+	}
+}
+
+func TestItemTimelineTracksUpdatedThenCompleted(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/updated",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution", "text": "ls"},
+		},
+	}, 0)
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/updated",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution", "text": "ls -la"},
+		},
+	}, 0)
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution", "text": "ls -la\nfile.go"},
+		},
+	}, 0)
+
+	timeline := result.ItemTimeline("item-1")
+	if len(timeline) != 3 {
+		t.Fatalf("len(timeline) = %d, want 3: %+v", len(timeline), timeline)
+	}
+	if timeline[0].Status != "updated" || timeline[1].Status != "updated" || timeline[2].Status != "completed" {
+		t.Fatalf("timeline statuses = %v, want [updated updated completed]",
+			[]string{timeline[0].Status, timeline[1].Status, timeline[2].Status})
+	}
+	if timeline[2].Item["text"] != "ls -la\nfile.go" {
+		t.Fatalf("timeline[2].Item = %v, want the final text", timeline[2].Item)
+	}
+}
+
+func TestItemTimelineTracksStartedThenUpdatedThenCompleted(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/started",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution", "text": ""},
+		},
+	}, 0)
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/updated",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution", "text": "ls"},
+		},
+	}, 0)
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution", "text": "ls\nfile.go"},
+		},
+	}, 0)
+
+	timeline := result.ItemTimeline("item-1")
+	if len(timeline) != 3 {
+		t.Fatalf("len(timeline) = %d, want 3: %+v", len(timeline), timeline)
+	}
+	if timeline[0].Status != "started" || timeline[1].Status != "updated" || timeline[2].Status != "completed" {
+		t.Fatalf("timeline statuses = %v, want [started updated completed]",
+			[]string{timeline[0].Status, timeline[1].Status, timeline[2].Status})
+	}
+}
+
+func TestInProgressItemsTracksStartedUntilCompleted(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/started",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution"},
+		},
+	}, 0)
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/started",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-2", "type": "file_change"},
+		},
+	}, 0)
+
+	inProgress := result.InProgressItems()
+	if len(inProgress) != 2 || inProgress[0]["id"] != "item-1" || inProgress[1]["id"] != "item-2" {
+		t.Fatalf("InProgressItems() = %v, want [item-1 item-2] in start order", inProgress)
+	}
+
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "command_execution"},
+		},
+	}, 0)
+
+	inProgress = result.InProgressItems()
+	if len(inProgress) != 1 || inProgress[0]["id"] != "item-2" {
+		t.Fatalf("InProgressItems() = %v, want only item-2 remaining", inProgress)
+	}
+}
+
+func TestItemTimelineEmptyForUnknownID(t *testing.T) {
+	var result TurnResult
+	updateTurnResult(&result, rpc.Notification{
+		Method: "item/completed",
+		Params: map[string]any{
+			"item": map[string]any{"id": "item-1", "type": "agent_message", "text": "done"},
+		},
+	}, 0)
+
+	if timeline := result.ItemTimeline("missing"); timeline != nil {
+		t.Fatalf("ItemTimeline(missing) = %v, want nil", timeline)
+	}
+}
+
+func TestAnnotatedTranscriptInterleavesStreamsInOrder(t *testing.T) {
+	result := TurnResult{
+		Items: []any{
+			map[string]any{"type": "reasoning", "text": "thinking it through", "timestamp": "2024-01-01T00:00:00Z"},
+			map[string]any{"type": "command_execution", "text": "ran ls", "timestamp": "2024-01-01T00:00:01Z"},
+			map[string]any{"type": "file_change", "text": "edited main.go", "timestamp": "2024-01-01T00:00:02Z"},
+			map[string]any{"type": "agent_message", "text": "done", "timestamp": "2024-01-01T00:00:03Z"},
+			map[string]any{"type": "turn_diff", "text": "ignored"},
+		},
+	}
+
+	segments := result.AnnotatedTranscript()
+	if len(segments) != 4 {
+		t.Fatalf("len(segments) = %d, want 4: %+v", len(segments), segments)
+	}
+
+	wantKinds := []string{"reasoning", "tool", "diff", "answer"}
+	for i, want := range wantKinds {
+		if segments[i].Kind != want {
+			t.Fatalf("segments[%d].Kind = %q, want %q", i, segments[i].Kind, want)
+		}
+	}
+	if segments[3].Text != "done" {
+		t.Fatalf("segments[3].Text = %q, want %q", segments[3].Text, "done")
+	}
+	if segments[0].At.IsZero() {
+		t.Fatalf("segments[0].At is zero, want the parsed timestamp")
+	}
+}
+
+func TestAnnotatedTranscriptMissingTimestampIsZero(t *testing.T) {
+	result := TurnResult{
+		Items: []any{
+			map[string]any{"type": "agent_message", "text": "done"},
+		},
+	}
+
+	segments := result.AnnotatedTranscript()
+	if len(segments) != 1 || !segments[0].At.IsZero() {
+		t.Fatalf("segments = %+v, want one segment with a zero time", segments)
+	}
+}
+
+func TestExtractProgress(t *testing.T) {
+	percent := extractProgress(rpc.Notification{
+		Method: "turn/progress",
+		Params: map[string]any{"percentage": 42.5},
+	})
+	if percent == nil || *percent != 42.5 {
+		t.Fatalf("extractProgress = %v, want 42.5", percent)
+	}
+
+	if percent := extractProgress(rpc.Notification{Method: "turn/progress", Params: map[string]any{}}); percent != nil {
+		t.Fatalf("extractProgress = %v, want nil", percent)
+	}
+}